@@ -0,0 +1,35 @@
+package buffer
+
+import "sync"
+
+const (
+	initialSize     = 1 << 10  // Initial capacity of a new buffer
+	maxRetainedSize = 64 << 10 // Buffers that grew past this size are left to the GC
+)
+
+var pool = sync.Pool{
+	New: func() any {
+		return &Buffer{B: make([]byte, 0, initialSize)}
+	},
+}
+
+// Buffer is a pooled byte slice used to build log entries without allocating.
+// A Buffer must not be used after Free is called.
+type Buffer struct {
+	B []byte
+}
+
+// Get returns an empty Buffer from the pool.
+func Get() *Buffer {
+	b := pool.Get().(*Buffer)
+	b.B = b.B[:0]
+	return b
+}
+
+// Free returns the Buffer to the pool.
+func (b *Buffer) Free() {
+	if cap(b.B) > maxRetainedSize {
+		return
+	}
+	pool.Put(b)
+}
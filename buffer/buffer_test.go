@@ -0,0 +1,27 @@
+package buffer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReturnsEmptyBuffer(t *testing.T) {
+	b := Get()
+	b.B = append(b.B, "log message"...)
+	b.Free()
+
+	b = Get()
+	assert.Empty(t, b.B)
+	assert.GreaterOrEqual(t, cap(b.B), initialSize)
+	b.Free()
+}
+
+func TestGetFreeDoesNotAllocate(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		b := Get()
+		b.B = append(b.B, "log message"...)
+		b.Free()
+	})
+	assert.Equal(t, float64(0), allocs)
+}
@@ -2,25 +2,28 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"os"
-	"slices"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/architagr/lognugget/buffer"
 	"github.com/architagr/lognugget/encoder"
 	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
 )
 
 var (
-	DafaultLevel       enum.LogLevel      = enum.LevelInfo   // Default log level
-	DafaultEncoderType enum.LogEncodeType = enum.EncoderJSON // Default encoder type
-	DafaultAddSource   bool               = true             // Default to add source information
-	DefaultOutput      io.Writer          = os.Stdout        // Default output writer
-	DefaultTimeFormat  string             = time.RFC822      // Default time format for log entries
-	DafaultLogBuffer   int                = 20               // Default buffer size for logs
-	DefaultPrefix      string             = "custom."
+	DafaultLevel        enum.LogLevel           = enum.LevelInfo   // Default log level
+	DafaultEncoderType  enum.LogEncodeType      = enum.EncoderJSON // Default encoder type
+	DafaultAddSource    bool                    = true             // Default to add source information
+	DefaultOutput       io.Writer               = os.Stdout        // Default output writer
+	DefaultTimeFormat   string                  = time.RFC3339     // Default time format for log entries
+	DefaultTimeZone     *time.Location          = time.UTC         // Default time zone the times are written in
+	DafaultLogBuffer    int                     = 20               // Default buffer size for logs
+	DefaultPrefix       string                  = "custom."
+	DefaultKeyCollision enum.KeyCollisionPolicy = enum.KeyCollisionPrefix // Default key collision policy
 )
 
 type PublishLogMessageHookContract interface {
@@ -30,10 +33,31 @@ type PublishLogMessageHookContract interface {
 type StaticEnvFieldsParser = func() map[string]any
 type ContextFieldsParser = func(ctx context.Context) map[string]any
 
+// ReplaceAttrFunc rewrites an attribute before it is encoded. An attribute returned with an empty key is dropped.
+//
+// groups is always nil, the attributes are flat when they are rewritten: the slog groups are
+// flattened by slogHandler into dotted keys such as "request.id", a map value is passed whole as
+// the value of its attribute and the "fields" group of enum.KeyCollisionNest is built afterwards.
+type ReplaceAttrFunc = func(groups []string, a model.LogAttr) model.LogAttr
+
+// StaticField is a static field with its value already encoded.
+type StaticField struct {
+	Key   string
+	Value string // encoded value, written as is between the quotes of the field
+}
+
+// AttrRedactor rewrites the attributes of every entry before they are encoded, e.g. redaction.Stage.
+type AttrRedactor interface {
+	RedactAttr(a model.LogAttr) model.LogAttr
+}
+
 func init() {
 	ResetConfig()
 }
 
+// Config is an immutable snapshot of the logger configuration, the setters
+// build a new snapshot and swap it in, so a snapshot returned by GetConfig
+// never changes while it is being read.
 type Config struct {
 	minLevel           enum.LogLevel                 // Minimum log level to log
 	encoderType        enum.LogEncodeType            // Encoder type to use for logging
@@ -42,83 +66,198 @@ type Config struct {
 	output             io.Writer                     // Output writer for logs
 	logBufferMaxSize   int                           // max Buffer size for logs
 	rate               time.Duration                 // Rate to push logs to output
+	staticParser       StaticEnvFieldsParser         // parser of the static fields, kept to parse them again when the redactor changes
 	parsedStaticFields string                        // this is the satic fields
+	staticAttrs        []StaticField                 // the static fields one by one, to resolve their key collisions
+	keyCollision       enum.KeyCollisionPolicy       // what happens to fields whose key is already used
+	redactor           AttrRedactor                  // redacts the attributes before they are encoded
+	replaceAttr        ReplaceAttrFunc               // rewrites every attribute before it is encoded
 	contextParser      ContextFieldsParser           // Function to extract context fields
 	defaultFields      map[enum.DefaultLogKey]string // Default fields to log with every entry
 	timeFormat         string                        // Time format for log entries
+	timeZone           *time.Location                // Time zone the times are written in
+	restrictedFields   []string                      // keys user supplied fields may not overwrite
 	hooks              map[enum.LogLevel]map[string]PublishLogMessageHookContract
 }
 
 type LogEvent struct {
-	Level enum.LogLevel
-	Data  []byte
+	Level   enum.LogLevel
+	Data    *buffer.Buffer
+	flushed chan struct{} // set on the events sent by Flush, which carry no entry
+}
+
+// Flusher is implemented by the pre processors and hooks holding entries,
+// Flush writes the entries they hold before it returns.
+type Flusher interface {
+	Flush()
+}
+
+// SyncPreProcessor is implemented by the pre processors able to write an entry before
+// PreProcessSync returns without flushing the entries they hold, see ProcessLogSync.
+type SyncPreProcessor interface {
+	PreProcessSync(level enum.LogLevel, logMsg []byte)
 }
 
 var (
-	defaultConfig      *Config
+	defaultConfig      atomic.Pointer[Config]
+	configMu           sync.Mutex // serializes the setters so no update is lost
 	ch                 chan LogEvent
-	EventPreProcessors map[string]preProcessingObserverContract
+	preProcessorsMu    sync.RWMutex
+	eventPreProcessors map[string]preProcessingObserverContract // replaced, never mutated, once published
 )
 
+// preProcessingObserverContract is implemented by the pipeline stages,
+// logMsg is only valid until PreProcess returns and must be copied to be retained.
 type preProcessingObserverContract interface {
 	PreProcess(level enum.LogLevel, logMsg []byte)
 	Name() string
 }
 
 func InitPreProcessors(observers ...preProcessingObserverContract) {
-	EventPreProcessors = make(map[string]preProcessingObserverContract)
-	AddPreProcessors(observers...)
+	preProcessors := make(map[string]preProcessingObserverContract, len(observers))
+	for _, observer := range observers {
+		preProcessors[observer.Name()] = observer
+	}
+	preProcessorsMu.Lock()
+	defer preProcessorsMu.Unlock()
+	eventPreProcessors = preProcessors
 }
 
 func AddPreProcessors(observers ...preProcessingObserverContract) {
-	for _, observer := range observers {
-		EventPreProcessors[observer.Name()] = observer
-	}
+	updatePreProcessors(func(preProcessors map[string]preProcessingObserverContract) {
+		for _, observer := range observers {
+			preProcessors[observer.Name()] = observer
+		}
+	})
 }
 
 func RemovePreProcessor(name string) {
-	delete(EventPreProcessors, name)
+	updatePreProcessors(func(preProcessors map[string]preProcessingObserverContract) {
+		delete(preProcessors, name)
+	})
+}
+
+// updatePreProcessors applies update to a copy of the registered pre processors
+// and publishes the copy, so the pipeline never reads a map that is being written.
+func updatePreProcessors(update func(preProcessors map[string]preProcessingObserverContract)) {
+	preProcessorsMu.Lock()
+	defer preProcessorsMu.Unlock()
+	preProcessors := make(map[string]preProcessingObserverContract, len(eventPreProcessors))
+	for name, observer := range eventPreProcessors {
+		preProcessors[name] = observer
+	}
+	update(preProcessors)
+	eventPreProcessors = preProcessors
+}
+
+// PreProcessors returns the registered pre processors, nil until InitPreProcessors
+// is called. The returned map must not be modified.
+func PreProcessors() map[string]preProcessingObserverContract {
+	preProcessorsMu.RLock()
+	defer preProcessorsMu.RUnlock()
+	return eventPreProcessors
+}
+
+// updateConfig applies update to a copy of the current config and publishes the copy,
+// readers keep using the snapshot they loaded and never see a half applied change.
+func updateConfig(update func(c *Config)) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	next := defaultConfig.Load().clone()
+	update(next)
+	defaultConfig.Store(next)
+}
+
+// clone returns a copy of c that shares nothing mutable with it.
+func (c *Config) clone() *Config {
+	next := *c
+	next.defaultFields = make(map[enum.DefaultLogKey]string, len(c.defaultFields))
+	for key, value := range c.defaultFields {
+		next.defaultFields[key] = value
+	}
+	next.hooks = make(map[enum.LogLevel]map[string]PublishLogMessageHookContract, len(c.hooks))
+	for level, levelHooks := range c.hooks {
+		next.hooks[level] = make(map[string]PublishLogMessageHookContract, len(levelHooks))
+		for name, hook := range levelHooks {
+			next.hooks[level][name] = hook
+		}
+	}
+	return &next
 }
 
 // SetMinLevel sets the minimum log level for the logger
 func SetMinLevel(level enum.LogLevel) {
-	defaultConfig.minLevel = level
+	updateConfig(func(c *Config) { c.setMinLevel(level) })
 }
 
-// SetMinLevel sets the minimum log level for the logger
+func (c *Config) setMinLevel(level enum.LogLevel) {
+	c.minLevel = level
+}
+
+// SetTimeFormat sets the time format of the log entries, a Go time layout such as
+// time.RFC3339Nano or one of the epoch formats customTime.FormatUnix, FormatUnixMilli,
+// FormatUnixMicro and FormatUnixNano written as JSON numbers.
 func SetTimeFormat(format string) {
-	defaultConfig.timeFormat = format
+	updateConfig(func(c *Config) { c.setTimeFormat(format) })
+}
+
+func (c *Config) setTimeFormat(format string) {
+	c.timeFormat = format
+}
+
+// SetTimeZone sets the time zone the times of the log entries are written in, e.g. time.Local, nil sets DefaultTimeZone.
+func SetTimeZone(loc *time.Location) {
+	updateConfig(func(c *Config) { c.setTimeZone(loc) })
+}
+
+func (c *Config) setTimeZone(loc *time.Location) {
+	if loc == nil {
+		loc = DefaultTimeZone
+	}
+	c.timeZone = loc
 }
 
 // SetEncoderType sets the encoder type for the logger
 func SetEncoderType(encoderType enum.LogEncodeType) {
+	updateConfig(func(c *Config) { c.setEncoderType(encoderType) })
+}
+
+func (c *Config) setEncoderType(encoderType enum.LogEncodeType) {
 	var err error
 
-	defaultConfig.encoderObj, err = encoder.DefaultEncoderFactory(encoderType)
+	c.encoderObj, err = encoder.DefaultEncoderFactory(encoderType)
 	if err != nil {
 		encoderType = enum.EncoderJSON
-		defaultConfig.encoderObj, _ = encoder.DefaultEncoderFactory(encoderType)
+		c.encoderObj, _ = encoder.DefaultEncoderFactory(encoderType)
 	}
 
-	defaultConfig.encoderType = encoderType
+	c.encoderType = encoderType
 }
 
 // SetAddSource sets whether to add source information to logs
 func SetAddSource(addSource bool) {
-	defaultConfig.addSource = addSource
+	updateConfig(func(c *Config) { c.setAddSource(addSource) })
+}
 
+func (c *Config) setAddSource(addSource bool) {
+	c.addSource = addSource
 }
 
 // SetOutput sets the output writer for the logger
 func SetOutput(output io.Writer) {
+	updateConfig(func(c *Config) { c.setOutput(output) })
+}
+
+func (c *Config) setOutput(output io.Writer) {
 	if output == nil {
 		output = DefaultOutput
 	}
-	defaultConfig.output = output
-
+	c.output = output
 }
 
-func PublishLog(Level enum.LogLevel, Data []byte) {
+// PublishLog hands the encoded entry to the pre processors, the buffer is
+// owned by the pipeline from here on and is freed once every pre processor ran.
+func PublishLog(Level enum.LogLevel, Data *buffer.Buffer) {
 	ch <- LogEvent{
 		Level: Level,
 		Data:  Data,
@@ -127,90 +266,147 @@ func PublishLog(Level enum.LogLevel, Data []byte) {
 
 // SetLogBufferMaxSize sets the maximum buffer size for logs
 func SetLogBufferMaxSize(size int) {
+	updateConfig(func(c *Config) { c.setLogBufferMaxSize(size) })
+}
+
+func (c *Config) setLogBufferMaxSize(size int) {
 	if size <= 0 {
 		size = 20 // Default buffer size
 	}
-	defaultConfig.logBufferMaxSize = size
-
+	c.logBufferMaxSize = size
 }
 
 // SetRate sets the rate at which logs are pushed to output
 func SetRate(rate time.Duration) {
+	updateConfig(func(c *Config) { c.setRate(rate) })
+}
+
+func (c *Config) setRate(rate time.Duration) {
 	if rate <= 0 {
 		rate = 1 * time.Second // Default rate is 1 sec
 	}
-	defaultConfig.rate = rate
-
+	c.rate = rate
 }
 
-var restrictedFields []string
+// SetStaticEnvFieldsParser sets the function to extract static environment fields
+func SetStaticEnvFieldsParser(parser StaticEnvFieldsParser) {
+	updateConfig(func(c *Config) { c.setStaticEnvFieldsParser(parser) })
+}
 
-func ValidateandParseLogField(key string, value any) string {
-	if slices.Contains(restrictedFields, key) {
-		key = DefaultPrefix + key
+func (c *Config) setStaticEnvFieldsParser(parser StaticEnvFieldsParser) {
+	c.staticParser = parser
+	c.staticAttrs = nil
+	c.parsedStaticFields = ""
+	if parser == nil {
+		return
 	}
-	return ParseLogField(key, value)
-}
-
-func ParseLogField(key string, value any) string {
-	sb := strings.Builder{}
-	sb.Grow(100 + len(key))
-	sb.WriteString("\"")
-	sb.WriteString(key)
-	sb.WriteString("\": \"")
-	switch value := value.(type) {
-	case string:
-		sb.WriteString(value)
-	case int, int16, int32, int64:
-		sb.WriteString(fmt.Sprintf("%d", value))
-	case float32, float64:
-		sb.WriteString(fmt.Sprintf("%f", value))
-	default:
-		sb.WriteString(fmt.Sprintf("%+v", value))
+	var data []byte
+	for key, value := range parser() {
+		field := model.LogAttr{Key: model.LogAttrKey(key), Value: model.LogAttrValue(value)}
+		if c.redactor != nil {
+			field = c.redactor.RedactAttr(field)
+		}
+		if c.replaceAttr != nil {
+			if field = c.replaceAttr(nil, field); field.Key == "" {
+				continue
+			}
+		}
+		c.staticAttrs = append(c.staticAttrs, StaticField{Key: string(field.Key), Value: string(appendValue(nil, field.Value))})
+		if len(data) > 0 {
+			data = AppendFieldSeparator(data)
+		}
+		data = AppendLogField(data, string(field.Key), field.Value)
 	}
-	sb.WriteString("\"")
-	return sb.String()
+	c.parsedStaticFields = string(data)
 }
 
-// SetStaticEnvFieldsParser sets the function to extract static environment fields
-func SetStaticEnvFieldsParser(parser StaticEnvFieldsParser) {
-	if parser != nil {
-		list := []string{}
-		for key, value := range parser() {
-			list = append(list, ValidateandParseLogField(key, value))
-		}
-		if len(list) > 0 {
-			defaultConfig.parsedStaticFields = strings.Join(list, ", ")
-		}
+// SetRedactor sets the redactor applied to the user, context and static fields
+// of every entry before it is encoded, nil disables redaction.
+func SetRedactor(redactor AttrRedactor) {
+	updateConfig(func(c *Config) {
+		c.redactor = redactor
+		c.setStaticEnvFieldsParser(c.staticParser)
+	})
+}
 
-	} else {
-		defaultConfig.parsedStaticFields = ""
-	}
+// SetReplaceAttr sets the function rewriting every attribute of an entry before it is
+// encoded, including the default time, level and message fields, nil disables it.
+func SetReplaceAttr(replace ReplaceAttrFunc) {
+	updateConfig(func(c *Config) {
+		c.replaceAttr = replace
+		c.setStaticEnvFieldsParser(c.staticParser)
+	})
+}
 
+// SetKeyCollisionPolicy sets what happens to the user, context and static fields whose key
+// is already used by the logger or an earlier field, an unknown policy is ignored.
+func SetKeyCollisionPolicy(policy enum.KeyCollisionPolicy) {
+	if !policy.Valid() {
+		return
+	}
+	updateConfig(func(c *Config) { c.keyCollision = policy })
 }
 
 // SetContextFieldsParser sets the function to extract context fields
 func SetContextFieldsParser(parser ContextFieldsParser) {
-	defaultConfig.contextParser = parser
-
+	updateConfig(func(c *Config) { c.contextParser = parser })
+}
+
+// Setup is the config of a logger set up in code, unlike Settings it holds values that
+// cannot be read from a file, a nil function disables what it does.
+type Setup struct {
+	Level         enum.LogLevel
+	EncoderType   enum.LogEncodeType
+	Output        io.Writer
+	LogBuffer     int
+	Rate          time.Duration
+	StaticFields  map[string]any
+	ContextParser ContextFieldsParser
+	Redactor      AttrRedactor
+	ReplaceAttr   ReplaceAttrFunc
+	KeyCollision  enum.KeyCollisionPolicy // an unknown policy keeps the current one
+}
+
+// ApplySetup applies every value of s in a single config update, so the loggers never see
+// a partly applied setup and the static fields are parsed once.
+func ApplySetup(s Setup) {
+	updateConfig(func(c *Config) {
+		c.setMinLevel(s.Level)
+		c.setEncoderType(s.EncoderType)
+		c.setOutput(s.Output)
+		c.setLogBufferMaxSize(s.LogBuffer)
+		c.setRate(s.Rate)
+		c.contextParser = s.ContextParser
+		c.redactor = s.Redactor
+		c.replaceAttr = s.ReplaceAttr
+		if s.KeyCollision.Valid() {
+			c.keyCollision = s.KeyCollision
+		}
+		var parser StaticEnvFieldsParser
+		if s.StaticFields != nil {
+			parser = func() map[string]any { return s.StaticFields }
+		}
+		c.setStaticEnvFieldsParser(parser)
+	})
 }
 
 func RegisterHook(level enum.LogLevel, hook PublishLogMessageHookContract) {
-	levelHooks, exists := defaultConfig.hooks[level]
-	if !exists {
-		levelHooks = make(map[string]PublishLogMessageHookContract)
-	}
-	levelHooks[hook.Name()] = hook
-	defaultConfig.hooks[level] = levelHooks
+	updateConfig(func(c *Config) {
+		levelHooks, exists := c.hooks[level]
+		if !exists {
+			levelHooks = make(map[string]PublishLogMessageHookContract)
+		}
+		levelHooks[hook.Name()] = hook
+		c.hooks[level] = levelHooks
+	})
 }
 
 func DeRegisterHook(level enum.LogLevel, hookName string) {
-	levelHooks, exists := defaultConfig.hooks[level]
-	if !exists {
-		return
-	}
-	delete(levelHooks, hookName)
-	defaultConfig.hooks[level] = levelHooks
+	updateConfig(func(c *Config) {
+		if levelHooks, exists := c.hooks[level]; exists {
+			delete(levelHooks, hookName)
+		}
+	})
 }
 
 // SetDefaultFields sets the default fields to log with every entry
@@ -218,30 +414,86 @@ func SetDefaultFields(fields map[enum.DefaultLogKey]string) {
 	if fields == nil {
 		return
 	}
+	updateConfig(func(c *Config) { c.setDefaultFields(fields) })
+}
+
+func (c *Config) setDefaultFields(fields map[enum.DefaultLogKey]string) {
 	for key, value := range fields {
 		if len(string(key)) == 0 || len(value) == 0 {
 			continue // Skip empty keys
 		}
-		defaultConfig.defaultFields[key] = value
+		c.defaultFields[key] = value
 	}
-	restrictedFields = []string{
-		defaultConfig.defaultFields[enum.DefaultLogKeyCaller],
-		defaultConfig.defaultFields[enum.DefaultLogKeyError],
-		defaultConfig.defaultFields[enum.DefaultLogKeyMessage],
-		defaultConfig.defaultFields[enum.DefaultLogKeyLevel],
-		defaultConfig.defaultFields[enum.DefaultLogKeyTime],
+	c.setRestrictedFields()
+}
+
+// setRestrictedFields refreshes the keys that user supplied fields may not
+// overwrite, these are the keys the logger itself writes on every entry.
+func (c *Config) setRestrictedFields() {
+	c.restrictedFields = []string{
+		c.defaultFields[enum.DefaultLogKeyCaller],
+		c.defaultFields[enum.DefaultLogKeyError],
+		c.defaultFields[enum.DefaultLogKeyMessage],
+		c.defaultFields[enum.DefaultLogKeyLevel],
+		c.defaultFields[enum.DefaultLogKeyTime],
 	}
 }
 
-// GetConfig returns the current logger configuration
+// GetConfig returns the current logger configuration, the snapshot
+// is not changed by later setters and is safe to read concurrently.
 func GetConfig() *Config {
-	return defaultConfig
+	return defaultConfig.Load()
+}
+
+// Flush blocks until the entries published so far went through the pre processors
+// and the pre processors implementing Flusher wrote the entries they hold.
+func Flush() {
+	flushed := make(chan struct{})
+	ch <- LogEvent{flushed: flushed}
+	<-flushed
 }
 
 func ProcessLogEvent() {
 	for e := range ch {
-		for _, observer := range EventPreProcessors {
-			observer.PreProcess(e.Level, e.Data)
+		if e.flushed != nil {
+			FlushPreProcessors()
+			close(e.flushed)
+			continue
+		}
+		ProcessLog(e.Level, e.Data.B)
+		e.Data.Free()
+	}
+}
+
+// ProcessLog hands the encoded entry to the pre processors on the calling goroutine
+// instead of the pipeline goroutine, logMsg is only used until ProcessLog returns.
+func ProcessLog(level enum.LogLevel, logMsg []byte) {
+	for _, observer := range PreProcessors() {
+		observer.PreProcess(level, logMsg)
+	}
+}
+
+// ProcessLogSync is ProcessLog writing the entry before it returns, the pre processors implementing
+// SyncPreProcessor write it without flushing the entries logged through the pipeline.
+func ProcessLogSync(level enum.LogLevel, logMsg []byte) {
+	for _, observer := range PreProcessors() {
+		if syncObserver, ok := observer.(SyncPreProcessor); ok {
+			syncObserver.PreProcessSync(level, logMsg)
+			continue
+		}
+		observer.PreProcess(level, logMsg)
+		if flusher, ok := observer.(Flusher); ok {
+			flusher.Flush()
+		}
+	}
+}
+
+// FlushPreProcessors flushes the pre processors implementing Flusher on the calling goroutine,
+// unlike Flush it does not wait for the entries published to the pipeline before.
+func FlushPreProcessors() {
+	for _, observer := range PreProcessors() {
+		if flusher, ok := observer.(Flusher); ok {
+			flusher.Flush()
 		}
 	}
 }
@@ -251,7 +503,7 @@ func ResetConfig() {
 	ch = make(chan LogEvent, 10)
 	go ProcessLogEvent()
 	encoderObj, _ := encoder.DefaultEncoderFactory(enum.EncoderJSON)
-	defaultConfig = &Config{
+	c := &Config{
 		minLevel:           DafaultLevel,
 		encoderType:        DafaultEncoderType,
 		encoderObj:         encoderObj,
@@ -262,12 +514,15 @@ func ResetConfig() {
 		parsedStaticFields: "",
 		contextParser:      nil,
 		timeFormat:         DefaultTimeFormat,
+		timeZone:           DefaultTimeZone,
+		keyCollision:       DefaultKeyCollision,
 		defaultFields: map[enum.DefaultLogKey]string{
 			enum.DefaultLogKeyTime:          string(enum.DefaultLogKeyTime),
 			enum.DefaultLogKeyLevel:         string(enum.DefaultLogKeyLevel),
 			enum.DefaultLogKeyMessage:       string(enum.DefaultLogKeyMessage),
 			enum.DefaultLogKeyError:         string(enum.DefaultLogKeyError),
 			enum.DefaultLogKeyCaller:        string(enum.DefaultLogKeyCaller),
+			enum.DefaultLogKeyStack:         string(enum.DefaultLogKeyStack),
 			enum.DefaultLogKeyContext:       string(enum.DefaultLogKeyContext),
 			enum.DefaultLogKeyDuration:      string(enum.DefaultLogKeyDuration),
 			enum.DefaultLogKeyFields:        string(enum.DefaultLogKeyFields),
@@ -292,6 +547,7 @@ func ResetConfig() {
 			enum.DefaultLogKeyResponseTime:  string(enum.DefaultLogKeyResponseTime),
 			enum.DefaultLogKeyClientIP:      string(enum.DefaultLogKeyClientIP),
 			enum.DefaultLogKeyServerIP:      string(enum.DefaultLogKeyServerIP),
+			enum.DefaultLogKeyTarget:        string(enum.DefaultLogKeyTarget),
 			enum.DefaultLogKeyProtocol:      string(enum.DefaultLogKeyProtocol),
 			enum.DefaultLogKeyMethod:        string(enum.DefaultLogKeyMethod),
 			enum.DefaultLogKeyURL:           string(enum.DefaultLogKeyURL),
@@ -305,7 +561,10 @@ func ResetConfig() {
 			enum.DefaultLogKeyForwardedFor:  string(enum.DefaultLogKeyForwardedFor),
 			enum.DefaultLogKeyCustom:        string(enum.DefaultLogKeyCustom),
 		},
+		hooks: make(map[enum.LogLevel]map[string]PublishLogMessageHookContract),
 	}
+	c.setRestrictedFields()
+	defaultConfig.Store(c)
 }
 
 func (c *Config) MinLevel() enum.LogLevel {
@@ -332,14 +591,39 @@ func (c *Config) Rate() time.Duration {
 	return c.rate
 }
 
+// StaticFields returns the encoded static fields, with their keys as given.
 func (c *Config) StaticFields() string {
 	return c.parsedStaticFields
 }
 
+// StaticAttrs returns the static fields one by one, the slice must not be modified.
+func (c *Config) StaticAttrs() []StaticField {
+	return c.staticAttrs
+}
+
+func (c *Config) KeyCollisionPolicy() enum.KeyCollisionPolicy {
+	return c.keyCollision
+}
+
+// ReservedKeys returns the keys of the fields written by the logger itself,
+// the slice must not be modified.
+func (c *Config) ReservedKeys() []string {
+	return c.restrictedFields
+}
+
+func (c *Config) ReplaceAttr() ReplaceAttrFunc {
+	return c.replaceAttr
+}
+
+func (c *Config) Redactor() AttrRedactor {
+	return c.redactor
+}
+
 func (c *Config) ContextParser() ContextFieldsParser {
 	return c.contextParser
 }
 
+// DefaultFields returns the key names of the default fields, the map is shared by the snapshot and must not be modified.
 func (c *Config) DefaultFields() map[enum.DefaultLogKey]string {
 	return c.defaultFields
 }
@@ -347,6 +631,15 @@ func (c *Config) DefaultFields() map[enum.DefaultLogKey]string {
 func (c *Config) TimeFormat() string {
 	return c.timeFormat
 }
+
+func (c *Config) TimeZone() *time.Location {
+	return c.timeZone
+}
+
+// AppendLogTimeField appends the field with t in the time zone and format of the config to dst.
+func (c *Config) AppendLogTimeField(dst []byte, key string, t time.Time) []byte {
+	return AppendLogTimeField(dst, key, t.In(c.timeZone), c.timeFormat)
+}
 func (c *Config) Encoder() encoder.Encoder {
 	return c.encoderObj
 }
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettersDoNotChangeLoadedSnapshot(t *testing.T) {
+	keepConfig(t)
+	SetMinLevel(enum.LevelInfo)
+	snapshot := GetConfig()
+
+	SetMinLevel(enum.LevelError)
+	SetDefaultFields(map[enum.DefaultLogKey]string{enum.DefaultLogKeyMessage: "msg"})
+
+	assert.Equal(t, enum.LevelInfo, snapshot.MinLevel())
+	assert.Equal(t, "message", snapshot.DefaultFields()[enum.DefaultLogKeyMessage])
+	assert.Equal(t, `"msg": "x"`, string(snapshot.AppendValidLogField(nil, "msg", "x")))
+	assert.Equal(t, enum.LevelError, GetConfig().MinLevel())
+	assert.Equal(t, `"custom.msg": "x"`, string(GetConfig().AppendValidLogField(nil, "msg", "x")))
+}
+
+func TestConcurrentSettersAndReaders(t *testing.T) {
+	keepConfig(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetMinLevel(enum.LevelDebug)
+				SetEncoderType(enum.EncoderText)
+				SetDefaultFields(map[enum.DefaultLogKey]string{enum.DefaultLogKeyTime: "ts"})
+				SetStaticEnvFieldsParser(func() map[string]any { return map[string]any{"worker": i} })
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cfg := GetConfig()
+				_ = cfg.MinLevel() <= enum.LevelInfo
+				_ = cfg.DefaultFields()[enum.DefaultLogKeyTime]
+				_ = cfg.Encoder()
+				_ = AppendValidLogField(nil, "ts", j)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "ts", GetConfig().DefaultFields()[enum.DefaultLogKeyTime])
+}
+
+type countingRedactor struct{ calls int }
+
+func (r *countingRedactor) RedactAttr(a model.LogAttr) model.LogAttr {
+	r.calls++
+	a.Value = "***"
+	return a
+}
+
+func TestApplySetupPublishesASingleSnapshot(t *testing.T) {
+	keepConfig(t)
+	SetMinLevel(enum.LevelInfo)
+	before := GetConfig()
+	redactor := &countingRedactor{}
+
+	ApplySetup(Setup{
+		Level:        enum.LevelDebug,
+		EncoderType:  enum.EncoderText,
+		Output:       os.Stderr,
+		LogBuffer:    5,
+		Rate:         time.Minute,
+		StaticFields: map[string]any{"token": "secret"},
+		Redactor:     redactor,
+		KeyCollision: enum.KeyCollisionSuffix,
+	})
+	cfg := GetConfig()
+	assert.Equal(t, enum.LevelInfo, before.MinLevel(), "the earlier snapshot is unchanged")
+	assert.Equal(t, enum.LevelDebug, cfg.MinLevel())
+	assert.Equal(t, enum.EncoderText, cfg.EncoderType())
+	assert.Equal(t, os.Stderr, cfg.Output())
+	assert.Equal(t, 5, cfg.LogBuffer())
+	assert.Equal(t, time.Minute, cfg.Rate())
+	assert.Equal(t, enum.KeyCollisionSuffix, cfg.KeyCollisionPolicy())
+	assert.Equal(t, `"token": "***"`, cfg.StaticFields(), "the static fields are redacted")
+	assert.Equal(t, 1, redactor.calls, "the static fields are parsed once")
+}
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/model"
+)
+
+const (
+	hexDigits = "0123456789abcdef"
+	// maxStackFrames bounds the frames written for a stack trace.
+	maxStackFrames = 32
+)
+
+// ValidateandParseLogField formats the field, prefixing keys that clash with the default fields.
+func ValidateandParseLogField(key string, value any) string {
+	return string(AppendValidLogField(nil, key, value))
+}
+
+// ParseLogField formats the field as `"key": "value"`.
+func ParseLogField(key string, value any) string {
+	return string(AppendLogField(nil, key, value))
+}
+
+// AppendValidLogField is AppendLogField with the keys that clash with the
+// default fields prefixed by DefaultPrefix.
+func AppendValidLogField(dst []byte, key string, value any) []byte {
+	return GetConfig().AppendValidLogField(dst, key, value)
+}
+
+// AppendValidLogField is AppendLogField with the keys that clash with the
+// default fields of c prefixed by DefaultPrefix.
+func (c *Config) AppendValidLogField(dst []byte, key string, value any) []byte {
+	if slices.Contains(c.restrictedFields, key) {
+		if err, ok := value.(error); ok {
+			return appendErrorField(dst, DefaultPrefix, key, err, nil, c.redactor)
+		}
+		dst = appendLogKey(dst, DefaultPrefix, key)
+		dst = appendValue(dst, value)
+		return append(dst, '"')
+	}
+	return AppendLogField(dst, key, value)
+}
+
+// AppendLogField appends the field formatted as `"key": "value"` to dst,
+// error values are appended as by AppendErrorField.
+func AppendLogField(dst []byte, key string, value any) []byte {
+	if err, ok := value.(error); ok {
+		return appendErrorField(dst, "", key, err, nil, nil)
+	}
+	dst = appendLogKey(dst, "", key)
+	dst = appendValue(dst, value)
+	return append(dst, '"')
+}
+
+// AppendEncodedField appends the field with its already encoded value to dst.
+func AppendEncodedField(dst []byte, key string, encoded string) []byte {
+	dst = appendLogKey(dst, "", key)
+	dst = append(dst, encoded...)
+	return append(dst, '"')
+}
+
+// AppendErrorField appends err as a group with its message and Go type, the errors it
+// wraps as the chain array, the stack trace and the fields added by the errors of the chain
+// implementing model.ErrorFielder:
+//
+//	"error": {"message": "load: not found", "type": "*fmt.wrapError", "chain": [{"message": "load: not found", "type": "*fmt.wrapError"}, {"message": "not found", "type": "*errors.errorString"}], "stack": ["main.load /app/main.go:12"], "status": "404"}
+//
+// The chain is left out when err wraps no error. The stack is the one of the innermost
+// error implementing model.StackTracer, or stack, e.g. captured at the log site, when none does.
+// An error field whose key is already used in the group is prefixed by DefaultPrefix, e.g. "custom.message".
+func AppendErrorField(dst []byte, key string, err error, stack []uintptr) []byte {
+	return appendErrorField(dst, "", key, err, stack, nil)
+}
+
+// AppendErrorField is AppendErrorField with the messages of the chain and the error
+// fields redacted by the redactor of the config.
+func (c *Config) AppendErrorField(dst []byte, key string, err error, stack []uintptr) []byte {
+	return appendErrorField(dst, "", key, err, stack, c.redactor)
+}
+
+func appendErrorField(dst []byte, prefix, key string, err error, stack []uintptr, redactor AttrRedactor) []byte {
+	dst = append(dst, '"')
+	dst = appendEscapedString(dst, prefix)
+	dst = appendEscapedString(dst, key)
+	dst = append(dst, "\": {"...)
+	dst = appendErrorMessage(dst, err, redactor)
+
+	chained := 0
+	model.ErrorChain(err, func(error) bool {
+		chained++
+		return chained < 2
+	})
+	if chained > 1 {
+		dst = append(dst, ", \"chain\": ["...)
+		n := 0
+		model.ErrorChain(err, func(err error) bool {
+			if n++; n > 1 {
+				dst = AppendFieldSeparator(dst)
+			}
+			dst = append(dst, '{')
+			dst = appendErrorMessage(dst, err, redactor)
+			dst = append(dst, '}')
+			return true
+		})
+		dst = append(dst, ']')
+	}
+
+	if errStack := model.ErrorStack(err); errStack != nil {
+		stack = errStack
+	}
+	if len(stack) > 0 {
+		dst = append(dst, ", \"stack\": ["...)
+		dst = appendStack(dst, stack)
+		dst = append(dst, ']')
+	}
+
+	var usedKeys []string
+	model.ErrorChain(err, func(err error) bool {
+		if fielder, ok := err.(model.ErrorFielder); ok {
+			for _, field := range fielder.ErrorFields() {
+				if redactor != nil {
+					field = redactor.RedactAttr(field)
+				}
+				key := errorFieldKey(string(field.Key), usedKeys)
+				usedKeys = append(usedKeys, key)
+				dst = AppendLogField(AppendFieldSeparator(dst), key, model.Resolve(field.Value))
+			}
+		}
+		return true
+	})
+	return append(dst, '}')
+}
+
+// errorGroupKeys are the keys appendErrorField writes in the error group itself.
+var errorGroupKeys = []string{"message", "type", "chain", "stack"}
+
+// errorFieldKey returns the key of an error field, prefixed by DefaultPrefix when it is one of
+// errorGroupKeys or was used by an earlier error field, and suffixed by _n while still used.
+func errorFieldKey(key string, used []string) string {
+	if !slices.Contains(errorGroupKeys, key) && !slices.Contains(used, key) {
+		return key
+	}
+	key = DefaultPrefix + key
+	unique := key
+	for n := 1; slices.Contains(used, unique); n++ {
+		unique = key + "_" + strconv.Itoa(n)
+	}
+	return unique
+}
+
+// appendErrorMessage appends the message and type fields of err, the message redacted by redactor.
+func appendErrorMessage(dst []byte, err error, redactor AttrRedactor) []byte {
+	if redactor != nil {
+		message := redactor.RedactAttr(model.LogAttr{Key: "message", Value: err.Error()})
+		dst = AppendLogField(dst, "message", model.Resolve(message.Value))
+	} else {
+		dst = AppendLogStringField(dst, "message", err.Error())
+	}
+	dst = AppendFieldSeparator(dst)
+	return AppendLogStringField(dst, "type", reflect.TypeOf(err).String())
+}
+
+// appendStack appends the frames of stack as strings formatted as "function file:line",
+// the program counters are return addresses as filled by runtime.Callers.
+func appendStack(dst []byte, stack []uintptr) []byte {
+	if len(stack) > maxStackFrames {
+		stack = stack[:maxStackFrames]
+	}
+	n := 0
+	for _, pc := range stack {
+		fn := runtime.FuncForPC(pc - 1)
+		if fn == nil {
+			continue
+		}
+		file, line := fn.FileLine(pc - 1)
+		if n++; n > 1 {
+			dst = AppendFieldSeparator(dst)
+		}
+		dst = append(dst, '"')
+		dst = appendEscapedString(dst, fn.Name())
+		dst = append(dst, ' ')
+		dst = appendEscapedString(dst, file)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(line), 10)
+		dst = append(dst, '"')
+	}
+	return dst
+}
+
+// AppendLogGroupBegin appends the key of a group, the fields of the group
+// are appended after it and the group is closed by AppendLogGroupEnd.
+func AppendLogGroupBegin(dst []byte, key string) []byte {
+	dst = append(dst, '"')
+	dst = appendEscapedString(dst, key)
+	return append(dst, "\": {"...)
+}
+
+// AppendLogGroupEnd closes a group opened by AppendLogGroupBegin.
+func AppendLogGroupEnd(dst []byte) []byte {
+	return append(dst, '}')
+}
+
+// AppendLogStringField is AppendLogField for string values, it avoids boxing the value.
+func AppendLogStringField(dst []byte, key string, value string) []byte {
+	dst = appendLogKey(dst, "", key)
+	dst = appendEscapedString(dst, value)
+	return append(dst, '"')
+}
+
+// AppendLogTimeField appends the field with t formatted by format to dst,
+// the epoch formats of customTime are appended as numbers without quotes.
+func AppendLogTimeField(dst []byte, key string, t time.Time, format string) []byte {
+	if customTime.IsEpochFormat(format) {
+		dst = append(dst, '"')
+		dst = appendEscapedString(dst, key)
+		dst = append(dst, "\": "...)
+		return customTime.AppendFormat(dst, t, format)
+	}
+	dst = appendLogKey(dst, "", key)
+	dst = customTime.AppendFormat(dst, t, format)
+	return append(dst, '"')
+}
+
+// AppendTime appends t in the time zone and format of the config to dst, as the value
+// of the time field is written.
+func (c *Config) AppendTime(dst []byte, t time.Time) []byte {
+	return customTime.AppendFormat(dst, t.In(c.timeZone), c.timeFormat)
+}
+
+// AppendFieldSeparator appends the separator written between two fields.
+func AppendFieldSeparator(dst []byte) []byte {
+	return append(dst, ", "...)
+}
+
+// appendLogKey appends the key and the opening quote of the value.
+func appendLogKey(dst []byte, prefix, key string) []byte {
+	dst = append(dst, '"')
+	dst = appendEscapedString(dst, prefix)
+	dst = appendEscapedString(dst, key)
+	return append(dst, "\": \""...)
+}
+
+func appendValue(dst []byte, value any) []byte {
+	switch value := value.(type) {
+	case string:
+		return appendEscapedString(dst, value)
+	case *model.Encoded:
+		return append(dst, value.B...)
+	case int:
+		return strconv.AppendInt(dst, int64(value), 10)
+	case int8:
+		return strconv.AppendInt(dst, int64(value), 10)
+	case int16:
+		return strconv.AppendInt(dst, int64(value), 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(value), 10)
+	case int64:
+		return strconv.AppendInt(dst, value, 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(value), 10)
+	case uint8:
+		return strconv.AppendUint(dst, uint64(value), 10)
+	case uint16:
+		return strconv.AppendUint(dst, uint64(value), 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(value), 10)
+	case uint64:
+		return strconv.AppendUint(dst, value, 10)
+	case float32:
+		return strconv.AppendFloat(dst, float64(value), 'f', 6, 32)
+	case float64:
+		return strconv.AppendFloat(dst, value, 'f', 6, 64)
+	case bool:
+		return strconv.AppendBool(dst, value)
+	case error:
+		return appendEscapedString(dst, value.Error())
+	case fmt.Stringer:
+		return appendEscapedString(dst, value.String())
+	case nil:
+		return append(dst, "<nil>"...)
+	default:
+		return appendEscapedString(dst, fmt.Sprintf("%+v", value))
+	}
+}
+
+// AppendEscapedString appends s escaped to be written between the quotes of a field value.
+func AppendEscapedString(dst []byte, s string) []byte {
+	return appendEscapedString(dst, s)
+}
+
+// appendEscapedString appends s escaped to be used inside a JSON string.
+func appendEscapedString(dst []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				dst = append(dst, s[start:i]...)
+				dst = append(dst, `�`...)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if c >= 0x20 && c != '"' && c != '\\' {
+			i++
+			continue
+		}
+		dst = append(dst, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		}
+		i++
+		start = i
+	}
+	return append(dst, s[start:]...)
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogField(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "string", value: "value", want: `"key": "value"`},
+		{name: "int", value: 42, want: `"key": "42"`},
+		{name: "int64", value: int64(-7), want: `"key": "-7"`},
+		{name: "uint", value: uint(7), want: `"key": "7"`},
+		{name: "float64", value: 1.5, want: `"key": "1.500000"`},
+		{name: "float32", value: float32(0.25), want: `"key": "0.250000"`},
+		{name: "bool", value: true, want: `"key": "true"`},
+		{name: "error", value: errors.New("not found"), want: `"key": {"message": "not found", "type": "*errors.errorString"}`},
+		{name: "stringer", value: 1500 * time.Millisecond, want: `"key": "1.5s"`},
+		{name: "nil", value: nil, want: `"key": "<nil>"`},
+		{name: "struct", value: struct{ ID int }{ID: 1}, want: `"key": "{ID:1}"`},
+		{name: "escaped", value: "say \"hi\"\n\tC:\\ \x01", want: `"key": "say \"hi\"\n\tC:\\ \u0001"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseLogField("key", tt.value))
+		})
+	}
+}
+
+func TestAppendLogFieldProducesValidJSON(t *testing.T) {
+	data := []byte{'{'}
+	data = AppendLogStringField(data, "message", "line 1\nline \"2\" \xff")
+	data = AppendLogField(AppendFieldSeparator(data), "user\"name", "alice")
+	data = AppendLogTimeField(AppendFieldSeparator(data), "time", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), time.RFC3339)
+	data = append(data, '}')
+
+	var m map[string]string
+	assert.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, "line 1\nline \"2\" �", m["message"])
+	assert.Equal(t, "alice", m["user\"name"])
+	assert.Equal(t, "2025-01-02T03:04:05Z", m["time"])
+}
+
+func TestAppendLogTimeFieldFormats(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: time.RFC3339, want: `"time": "2025-01-02T03:04:05Z"`},
+		{format: time.RFC3339Nano, want: `"time": "2025-01-02T03:04:05.123456789Z"`},
+		{format: "2006-01-02 15:04:05,000 MST", want: `"time": "2025-01-02 03:04:05,123 UTC"`},
+		{format: customTime.FormatUnix, want: `"time": 1735787045`},
+		{format: customTime.FormatUnixMilli, want: `"time": 1735787045123`},
+		{format: customTime.FormatUnixMicro, want: `"time": 1735787045123456`},
+		{format: customTime.FormatUnixNano, want: `"time": 1735787045123456789`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(AppendLogTimeField(nil, "time", at, tt.format)))
+		})
+	}
+}
+
+func TestAppendLogTimeFieldReusesTheSecondOnlyForTheSameSecond(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	times := []time.Time{at, at.Add(120 * time.Millisecond), at.Add(time.Second), at.Add(time.Second).In(time.FixedZone("CET", 3600))}
+	for _, format := range []string{time.RFC3339Nano, time.StampMicro, time.RFC1123, "15:04:05.000 .999999"} {
+		for _, tm := range times {
+			assert.Equal(t, `"time": "`+tm.Format(format)+`"`, string(AppendLogTimeField(nil, "time", tm, format)))
+		}
+	}
+}
+
+func TestConfigAppendLogTimeFieldUsesTheTimeZone(t *testing.T) {
+	keepConfig(t)
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetTimeFormat(time.RFC3339)
+	SetTimeZone(time.FixedZone("IST", 5*3600+1800))
+	assert.Equal(t, `"time": "2025-01-02T08:34:05+05:30"`, string(GetConfig().AppendLogTimeField(nil, "time", at)))
+
+	SetTimeZone(nil)
+	assert.Equal(t, `"time": "2025-01-02T03:04:05Z"`, string(GetConfig().AppendLogTimeField(nil, "time", at.In(time.Local))))
+}
+
+func TestAppendValidLogFieldPrefixesRestrictedKeys(t *testing.T) {
+	assert.Equal(t, `"custom.message": "value"`, string(AppendValidLogField(nil, "message", "value")))
+	assert.Equal(t, `"user": "value"`, string(AppendValidLogField(nil, "user", "value")))
+}
+
+func TestRestrictedKeysFollowTheDefaultFields(t *testing.T) {
+	keepConfig(t)
+	SetDefaultFields(map[enum.DefaultLogKey]string{enum.DefaultLogKeyMessage: "msg"})
+	assert.Equal(t, `"custom.msg": "value"`, string(AppendValidLogField(nil, "msg", "value")))
+	assert.Equal(t, `"message": "value"`, string(AppendValidLogField(nil, "message", "value")))
+
+	SetDefaultFields(map[enum.DefaultLogKey]string{enum.DefaultLogKeyMessage: "message"})
+	assert.Equal(t, `"msg": "value"`, string(AppendValidLogField(nil, "msg", "value")))
+	assert.Equal(t, `"custom.message": "value"`, string(AppendValidLogField(nil, "message", "value")))
+}
+
+type stackError struct {
+	stack []uintptr
+}
+
+func (e stackError) Error() string         { return "with stack" }
+func (e stackError) StackTrace() []uintptr { return e.stack }
+
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("status %d", e.status) }
+func (e *statusError) ErrorFields() []model.LogAttr {
+	return []model.LogAttr{{Key: "status", Value: e.status}}
+}
+
+func TestAppendErrorField(t *testing.T) {
+	var pcs [8]uintptr
+	stack := pcs[:runtime.Callers(1, pcs[:])]
+	err := fmt.Errorf("load: %w", errors.Join(&statusError{status: 404}, stackError{stack: stack}))
+
+	data := append(AppendErrorField([]byte{'{'}, "error", err, nil), '}')
+	var m struct {
+		Error struct {
+			Message string
+			Type    string
+			Chain   []struct{ Message, Type string }
+			Stack   []string
+			Status  string
+		}
+	}
+	assert.NoError(t, json.Unmarshal(data, &m), string(data))
+	assert.Equal(t, "load: status 404\nwith stack", m.Error.Message)
+	assert.Equal(t, "*fmt.wrapError", m.Error.Type)
+	assert.Equal(t, []struct{ Message, Type string }{
+		{Message: "load: status 404\nwith stack", Type: "*fmt.wrapError"},
+		{Message: "status 404\nwith stack", Type: "*errors.joinError"},
+		{Message: "status 404", Type: "*config.statusError"},
+		{Message: "with stack", Type: "config.stackError"},
+	}, m.Error.Chain)
+	assert.Equal(t, "404", m.Error.Status)
+	assert.NotEmpty(t, m.Error.Stack)
+	assert.Contains(t, m.Error.Stack[0], "config.TestAppendErrorField ")
+	assert.Contains(t, m.Error.Stack[0], "field_test.go:")
+}
+
+func TestAppendErrorFieldUsesTheGivenStackWhenTheErrorHasNone(t *testing.T) {
+	err := errors.New("not found")
+	assert.Equal(t, `"error": {"message": "not found", "type": "*errors.errorString"}`, string(AppendErrorField(nil, "error", err, nil)))
+
+	var pcs [8]uintptr
+	data := string(AppendErrorField(nil, "error", err, pcs[:runtime.Callers(1, pcs[:])]))
+	assert.Contains(t, data, `"stack": ["github.com/architagr/lognugget/config.TestAppendErrorFieldUsesTheGivenStackWhenTheErrorHasNone `)
+	assert.NotContains(t, data, "chain")
+}
+
+type fieldsError struct {
+	fields []model.LogAttr
+}
+
+func (e fieldsError) Error() string                { return "with fields" }
+func (e fieldsError) ErrorFields() []model.LogAttr { return e.fields }
+
+func TestAppendErrorFieldPrefixesTheErrorFieldsUsingAKeyOfTheGroup(t *testing.T) {
+	err := fmt.Errorf("load: %w", fieldsError{fields: []model.LogAttr{
+		{Key: "message", Value: "shadow"},
+		{Key: "stack", Value: "none"},
+		{Key: "status", Value: 404},
+		{Key: "status", Value: 500},
+		{Key: "message", Value: "again"},
+	}})
+
+	data := append(AppendErrorField([]byte{'{'}, "error", err, nil), '}')
+	var m map[string]map[string]any
+	assert.NoError(t, json.Unmarshal(data, &m), string(data))
+	assert.Equal(t, "load: with fields", m["error"]["message"])
+	assert.Equal(t, "shadow", m["error"][DefaultPrefix+"message"])
+	assert.Equal(t, "again", m["error"][DefaultPrefix+"message_1"])
+	assert.Equal(t, "none", m["error"][DefaultPrefix+"stack"])
+	assert.Equal(t, "404", m["error"]["status"])
+	assert.Equal(t, "500", m["error"][DefaultPrefix+"status"])
+	assert.Len(t, m["error"], 8, "a key written twice is decoded once")
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/architagr/lognugget/encoder"
+	"github.com/architagr/lognugget/enum"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultEnvPrefix = "LOGNUGGET" // Prefix of the environment variables read by LoadFromEnv
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+var (
+	ErrInvalidSetting        = errors.New("invalid logger setting")
+	ErrUnsupportedFileFormat = errors.New("unsupported config file format, use .json, .yaml or .yml")
+)
+
+// Settings are the logger settings that can be read from the environment or a file,
+// an empty value leaves the current setting unchanged.
+type Settings struct {
+	Level         string            `json:"level,omitempty" yaml:"level,omitempty"`                   // Level name as read by enum.ParseLevel
+	Encoder       string            `json:"encoder,omitempty" yaml:"encoder,omitempty"`               // json or text
+	TimeFormat    string            `json:"time_format,omitempty" yaml:"time_format,omitempty"`       // Go time layout, or unix, unix_ms, unix_us or unix_ns
+	TimeZone      string            `json:"time_zone,omitempty" yaml:"time_zone,omitempty"`           // UTC, Local or a zone name as read by time.LoadLocation
+	Output        string            `json:"output,omitempty" yaml:"output,omitempty"`                 // stdout, stderr or a file path the logs are appended to
+	AddSource     *bool             `json:"add_source,omitempty" yaml:"add_source,omitempty"`         // Whether to add source information to logs
+	Buffer        int               `json:"buffer,omitempty" yaml:"buffer,omitempty"`                 // max Buffer size for logs
+	Rate          string            `json:"rate,omitempty" yaml:"rate,omitempty"`                     // Rate to push logs to output, as read by time.ParseDuration
+	KeyCollision  string            `json:"key_collision,omitempty" yaml:"key_collision,omitempty"`   // prefix, suffix, last_wins, first_wins or nest
+	StaticFields  map[string]string `json:"static_fields,omitempty" yaml:"static_fields,omitempty"`   // Fields added to every entry
+	DefaultFields map[string]string `json:"default_fields,omitempty" yaml:"default_fields,omitempty"` // Renames of the default keys, e.g. time: ts
+}
+
+// LoadFromEnv applies the settings read from the environment variables named
+// <prefix>_LEVEL, _ENCODER, _TIME_FORMAT, _TIME_ZONE, _OUTPUT, _ADD_SOURCE, _BUFFER, _RATE,
+// _KEY_COLLISION, _STATIC_FIELDS and _DEFAULT_FIELDS, the last two as comma separated key=value pairs.
+// An empty prefix uses DefaultEnvPrefix. Nothing is applied when a value is invalid.
+func LoadFromEnv(prefix string) error {
+	settings, err := SettingsFromEnv(prefix)
+	if err != nil {
+		return err
+	}
+	return Apply(settings)
+}
+
+// LoadFromFile applies the settings read from a JSON or YAML file, the format is
+// chosen by the file extension. Nothing is applied when a value is invalid.
+func LoadFromFile(path string) error {
+	settings, err := SettingsFromFile(path)
+	if err != nil {
+		return err
+	}
+	return Apply(settings)
+}
+
+// SettingsFromEnv reads the settings from the environment, see LoadFromEnv.
+func SettingsFromEnv(prefix string) (Settings, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+	lookup := func(name string) string {
+		return strings.TrimSpace(os.Getenv(prefix + name))
+	}
+
+	settings := Settings{
+		Level:        lookup("LEVEL"),
+		Encoder:      lookup("ENCODER"),
+		TimeFormat:   lookup("TIME_FORMAT"),
+		TimeZone:     lookup("TIME_ZONE"),
+		Output:       lookup("OUTPUT"),
+		Rate:         lookup("RATE"),
+		KeyCollision: lookup("KEY_COLLISION"),
+	}
+	if value := lookup("ADD_SOURCE"); value != "" {
+		addSource, err := strconv.ParseBool(value)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%w: %sADD_SOURCE %q is not a boolean", ErrInvalidSetting, prefix, value)
+		}
+		settings.AddSource = &addSource
+	}
+	if value := lookup("BUFFER"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return Settings{}, fmt.Errorf("%w: %sBUFFER %q is not an integer", ErrInvalidSetting, prefix, value)
+		}
+		settings.Buffer = size
+	}
+	var err error
+	if settings.StaticFields, err = parseKeyValueList(prefix+"STATIC_FIELDS", lookup("STATIC_FIELDS")); err != nil {
+		return Settings{}, err
+	}
+	if settings.DefaultFields, err = parseKeyValueList(prefix+"DEFAULT_FIELDS", lookup("DEFAULT_FIELDS")); err != nil {
+		return Settings{}, err
+	}
+	return settings, settings.Validate()
+}
+
+// SettingsFromFile reads the settings from a JSON or YAML file, unknown keys are an error.
+func SettingsFromFile(path string) (Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Settings{}, err
+	}
+	var settings Settings
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&settings)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&settings)
+	default:
+		return Settings{}, fmt.Errorf("%w: %s", ErrUnsupportedFileFormat, path)
+	}
+	if err != nil && err != io.EOF {
+		return Settings{}, fmt.Errorf("%w: %s: %v", ErrInvalidSetting, path, err)
+	}
+	if err := settings.Validate(); err != nil {
+		return Settings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// parseKeyValueList parses "k1=v1,k2=v2", name is the variable reported in errors.
+func parseKeyValueList(name, value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(pair, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%w: %s entry %q is not a key=value pair", ErrInvalidSetting, name, pair)
+		}
+		pairs[key] = val
+	}
+	return pairs, nil
+}
+
+// Validate checks every set value, the error names the first invalid setting.
+func (s Settings) Validate() error {
+	if s.Level != "" {
+		if _, err := enum.ParseLevel(s.Level); err != nil {
+			return fmt.Errorf("%w: level: %v", ErrInvalidSetting, err)
+		}
+	}
+	if s.Encoder != "" {
+		if _, err := encoder.DefaultEncoderFactory(enum.LogEncodeType(strings.ToLower(s.Encoder))); err != nil {
+			return fmt.Errorf("%w: encoder %q: %v", ErrInvalidSetting, s.Encoder, err)
+		}
+	}
+	if s.TimeZone != "" {
+		if _, err := time.LoadLocation(s.TimeZone); err != nil {
+			return fmt.Errorf("%w: time_zone: %v", ErrInvalidSetting, err)
+		}
+	}
+	if s.Buffer < 0 {
+		return fmt.Errorf("%w: buffer %d must be positive", ErrInvalidSetting, s.Buffer)
+	}
+	if s.Rate != "" {
+		rate, err := time.ParseDuration(s.Rate)
+		if err != nil {
+			return fmt.Errorf("%w: rate: %v", ErrInvalidSetting, err)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("%w: rate %q must be positive", ErrInvalidSetting, s.Rate)
+		}
+	}
+	if s.KeyCollision != "" && !enum.KeyCollisionPolicy(strings.ToLower(s.KeyCollision)).Valid() {
+		return fmt.Errorf("%w: unknown key collision policy %q", ErrInvalidSetting, s.KeyCollision)
+	}
+	defaultFields := GetConfig().DefaultFields()
+	for key, name := range s.DefaultFields {
+		if _, exists := defaultFields[enum.DefaultLogKey(key)]; !exists {
+			return fmt.Errorf("%w: default_fields: unknown default key %q", ErrInvalidSetting, key)
+		}
+		if name == "" {
+			return fmt.Errorf("%w: default_fields: key %q is renamed to an empty name", ErrInvalidSetting, key)
+		}
+	}
+	for key := range s.StaticFields {
+		if key == "" {
+			return fmt.Errorf("%w: static_fields: empty key", ErrInvalidSetting)
+		}
+	}
+	return nil
+}
+
+// Apply validates settings and applies every set value in a single config update,
+// nothing is applied when a value is invalid or the output cannot be opened.
+func Apply(s Settings) error {
+	return ApplyOver(nil, s)
+}
+
+// ApplyOver is Apply with every setting left out of s reset to its value in base first,
+// e.g. the config before a file was first applied, so a setting removed from the file
+// is reverted. The hooks, redactor and other values no file sets are kept. A nil base is Apply.
+func ApplyOver(base *Config, s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	var output io.Writer
+	if s.Output != "" {
+		var err error
+		if output, err = openOutput(s.Output); err != nil {
+			return fmt.Errorf("%w: output: %v", ErrInvalidSetting, err)
+		}
+	}
+
+	updateConfig(func(c *Config) {
+		if base != nil {
+			c.resetSettings(base)
+		}
+		if s.Level != "" {
+			level, _ := enum.ParseLevel(s.Level)
+			c.setMinLevel(level)
+		}
+		if s.Encoder != "" {
+			c.setEncoderType(enum.LogEncodeType(strings.ToLower(s.Encoder)))
+		}
+		if s.TimeFormat != "" {
+			c.setTimeFormat(s.TimeFormat)
+		}
+		if s.TimeZone != "" {
+			loc, _ := time.LoadLocation(s.TimeZone)
+			c.setTimeZone(loc)
+		}
+		if output != nil {
+			c.setOutput(output)
+		}
+		if s.AddSource != nil {
+			c.setAddSource(*s.AddSource)
+		}
+		if s.Buffer > 0 {
+			c.setLogBufferMaxSize(s.Buffer)
+		}
+		if s.Rate != "" {
+			rate, _ := time.ParseDuration(s.Rate)
+			c.setRate(rate)
+		}
+		if s.KeyCollision != "" {
+			c.keyCollision = enum.KeyCollisionPolicy(strings.ToLower(s.KeyCollision))
+		}
+		if len(s.DefaultFields) > 0 {
+			defaultFields := make(map[enum.DefaultLogKey]string, len(s.DefaultFields))
+			for key, name := range s.DefaultFields {
+				defaultFields[enum.DefaultLogKey(key)] = name
+			}
+			c.setDefaultFields(defaultFields)
+		}
+		if s.StaticFields != nil {
+			staticFields := make(map[string]any, len(s.StaticFields))
+			for key, value := range s.StaticFields {
+				staticFields[key] = value
+			}
+			c.setStaticEnvFieldsParser(func() map[string]any { return staticFields })
+		}
+	})
+	return nil
+}
+
+// resetSettings sets every value a Settings can hold to its value in base.
+func (c *Config) resetSettings(base *Config) {
+	c.minLevel = base.minLevel
+	c.encoderType, c.encoderObj = base.encoderType, base.encoderObj
+	c.timeFormat, c.timeZone = base.timeFormat, base.timeZone
+	c.output = base.output
+	c.addSource = base.addSource
+	c.logBufferMaxSize, c.rate = base.logBufferMaxSize, base.rate
+	c.keyCollision = base.keyCollision
+	c.defaultFields = make(map[enum.DefaultLogKey]string, len(base.defaultFields))
+	for key, value := range base.defaultFields {
+		c.defaultFields[key] = value
+	}
+	c.setRestrictedFields()
+	c.setStaticEnvFieldsParser(base.staticParser)
+}
+
+var (
+	outputFilesMu sync.Mutex
+	outputFiles   = map[string]*os.File{} // files opened as output, reused when the same path is loaded again
+)
+
+// openOutput returns the writer for stdout, stderr or the file at path,
+// files are opened for appending and stay open since entries may still be written to them.
+func openOutput(path string) (io.Writer, error) {
+	switch strings.ToLower(path) {
+	case OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	}
+	outputFilesMu.Lock()
+	defer outputFilesMu.Unlock()
+	if file, exists := outputFiles[path]; exists {
+		return file, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	outputFiles[path] = file
+	return file, nil
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+// keepConfig restores the config as it was before the test once it finished.
+func keepConfig(t *testing.T) {
+	saved := GetConfig()
+	t.Cleanup(func() {
+		defaultConfig.Store(saved)
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	keepConfig(t)
+	t.Setenv("APP_LEVEL", "warn")
+	t.Setenv("APP_ENCODER", "TEXT")
+	t.Setenv("APP_TIME_FORMAT", time.RFC3339Nano)
+	t.Setenv("APP_TIME_ZONE", "Local")
+	t.Setenv("APP_OUTPUT", "stderr")
+	t.Setenv("APP_ADD_SOURCE", "false")
+	t.Setenv("APP_BUFFER", "50")
+	t.Setenv("APP_RATE", "250ms")
+	t.Setenv("APP_KEY_COLLISION", "Last_Wins")
+	t.Setenv("APP_STATIC_FIELDS", "service=billing, env=prod")
+	t.Setenv("APP_DEFAULT_FIELDS", "time=ts,message=msg")
+
+	assert.NoError(t, LoadFromEnv("APP"))
+	cfg := GetConfig()
+	assert.Equal(t, enum.LevelWarn, cfg.MinLevel())
+	assert.Equal(t, enum.EncoderText, cfg.EncoderType())
+	assert.Equal(t, time.RFC3339Nano, cfg.TimeFormat())
+	assert.Equal(t, time.Local, cfg.TimeZone())
+	assert.Equal(t, os.Stderr, cfg.Output())
+	assert.False(t, cfg.AddSource())
+	assert.Equal(t, 50, cfg.LogBuffer())
+	assert.Equal(t, 250*time.Millisecond, cfg.Rate())
+	assert.Equal(t, enum.KeyCollisionLastWins, cfg.KeyCollisionPolicy())
+	assert.Contains(t, cfg.StaticFields(), ParseLogField("service", "billing"))
+	assert.Contains(t, cfg.StaticFields(), ParseLogField("env", "prod"))
+	assert.Equal(t, "ts", cfg.DefaultFields()[enum.DefaultLogKeyTime])
+	assert.Equal(t, "msg", cfg.DefaultFields()[enum.DefaultLogKeyMessage])
+	assert.Equal(t, `"custom.msg": "x"`, ValidateandParseLogField("msg", "x"), "renamed keys are restricted")
+}
+
+func TestLoadFromEnvRejectsInvalidValues(t *testing.T) {
+	keepConfig(t)
+	SetMinLevel(enum.LevelInfo)
+	tests := map[string]string{
+		"LOGNUGGET_LEVEL":          "loud",
+		"LOGNUGGET_ENCODER":        "xml",
+		"LOGNUGGET_BUFFER":         "many",
+		"LOGNUGGET_RATE":           "-1s",
+		"LOGNUGGET_TIME_ZONE":      "Mars/Olympus_Mons",
+		"LOGNUGGET_KEY_COLLISION":  "overwrite",
+		"LOGNUGGET_ADD_SOURCE":     "maybe",
+		"LOGNUGGET_DEFAULT_FIELDS": "unknown_key=x",
+		"LOGNUGGET_STATIC_FIELDS":  "no-value",
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("LOGNUGGET_LEVEL", "debug")
+			t.Setenv(name, value)
+			err := LoadFromEnv("")
+			assert.ErrorIs(t, err, ErrInvalidSetting)
+			assert.Equal(t, enum.LevelInfo, GetConfig().MinLevel(), "nothing is applied when a value is invalid")
+		})
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"level": "error", "encoder": "text", "buffer": 5, "rate": "2s", "key_collision": "nest",
+			"static_fields": {"service": "billing"}, "default_fields": {"level": "severity"}}`,
+		"config.yaml": "level: error\nencoder: text\nbuffer: 5\nrate: 2s\nkey_collision: nest\nstatic_fields:\n  service: billing\ndefault_fields:\n  level: severity\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			keepConfig(t)
+			assert.NoError(t, LoadFromFile(writeFile(t, name, content)))
+			cfg := GetConfig()
+			assert.Equal(t, enum.LevelError, cfg.MinLevel())
+			assert.Equal(t, enum.EncoderText, cfg.EncoderType())
+			assert.Equal(t, 5, cfg.LogBuffer())
+			assert.Equal(t, 2*time.Second, cfg.Rate())
+			assert.Equal(t, enum.KeyCollisionNest, cfg.KeyCollisionPolicy())
+			assert.Equal(t, ParseLogField("service", "billing"), cfg.StaticFields())
+			assert.Equal(t, "severity", cfg.DefaultFields()[enum.DefaultLogKeyLevel])
+		})
+	}
+}
+
+func TestApplyOverRevertsTheSettingsLeftOut(t *testing.T) {
+	keepConfig(t)
+	base := GetConfig()
+	assert.NoError(t, ApplyOver(base, Settings{
+		Level: "error", Encoder: "text", KeyCollision: "nest",
+		StaticFields: map[string]string{"service": "billing"}, DefaultFields: map[string]string{"level": "severity"},
+	}))
+	contextParser := func(context.Context) map[string]any { return nil }
+	SetContextFieldsParser(contextParser)
+
+	assert.NoError(t, ApplyOver(base, Settings{Level: "warn"}))
+	cfg := GetConfig()
+	assert.Equal(t, enum.LevelWarn, cfg.MinLevel())
+	assert.Equal(t, base.EncoderType(), cfg.EncoderType())
+	assert.Equal(t, base.KeyCollisionPolicy(), cfg.KeyCollisionPolicy())
+	assert.Equal(t, base.StaticFields(), cfg.StaticFields())
+	assert.Equal(t, base.DefaultFields(), cfg.DefaultFields())
+	assert.Equal(t, ParseLogField("custom.level", "x"), string(cfg.AppendValidLogField(nil, "level", "x")))
+	assert.NotNil(t, cfg.ContextParser(), "the values no file sets are kept")
+}
+
+func TestLoadFromFileOpensOutputFile(t *testing.T) {
+	keepConfig(t)
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	path := writeFile(t, "config.yml", "output: "+logPath+"\n")
+	assert.NoError(t, LoadFromFile(path))
+	assert.NoError(t, LoadFromFile(path))
+	output, ok := GetConfig().Output().(*os.File)
+	assert.True(t, ok)
+	assert.Equal(t, logPath, output.Name())
+	assert.Same(t, outputFiles[logPath], output, "the same path reuses the open file")
+}
+
+func TestLoadFromFileErrors(t *testing.T) {
+	keepConfig(t)
+	_, err := SettingsFromFile(writeFile(t, "config.toml", "level = 'info'"))
+	assert.ErrorIs(t, err, ErrUnsupportedFileFormat)
+
+	err = LoadFromFile(writeFile(t, "config.json", `{"levle": "info"}`))
+	assert.ErrorIs(t, err, ErrInvalidSetting)
+	assert.Contains(t, err.Error(), "levle")
+
+	err = LoadFromFile(writeFile(t, "config.yaml", "level: info\nbuffer: -1\n"))
+	assert.ErrorIs(t, err, ErrInvalidSetting)
+	assert.Contains(t, err.Error(), "buffer")
+
+	err = LoadFromFile(writeFile(t, "config.yaml", "output: "+filepath.Join(t.TempDir(), "missing", "app.log")+"\n"))
+	assert.ErrorIs(t, err, ErrInvalidSetting)
+
+	assert.Error(t, LoadFromFile(filepath.Join(t.TempDir(), "missing.json")))
+	assert.NoError(t, LoadFromFile(writeFile(t, "empty.yaml", "")))
+}
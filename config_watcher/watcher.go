@@ -0,0 +1,229 @@
+package configWatcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const (
+	DefaultInterval  = time.Second // Default interval between two checks of the file
+	ReloadedMsg      = "logger config reloaded"
+	RejectedMsg      = "logger config reload rejected, keeping the previous config"
+	changesSeparator = "; "
+)
+
+// Sink is a post processor whose batching and output follow the config,
+// e.g. the one created by pipelineStage.NewUnsetLogEventPostProcessor.
+type Sink interface {
+	Reconfigure(rate time.Duration, maxBufferSize int, output io.Writer)
+}
+
+// Options are the options of a Watcher.
+type Options struct {
+	Interval time.Duration   // Interval between two checks of the file, defaults to DefaultInterval
+	Logger   *entry.LogEntry // Logger the reloads are reported to, defaults to a new entry
+	Sinks    []Sink          // Sinks reconfigured when the output, buffer or rate changes
+}
+
+// Watcher polls the modification time of a config file and applies it again when it changes.
+// Each version of the file is applied over the config the watcher started with, so a setting
+// removed from the file is reverted. A file that fails to parse or validate is rejected and
+// the previous config stays in place.
+type Watcher struct {
+	path     string
+	opts     Options
+	base     *config.Config  // config before the file was first applied
+	logger   *entry.LogEntry // Options.Logger writing the reloads whatever the minimum level
+	settings config.Settings // settings of the last file that was applied
+	modTime  time.Time
+	size     int64
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// Watch applies the file at path and starts watching it, an error is returned
+// when the file cannot be applied, in which case nothing is watched.
+func Watch(path string, opts *Options) (*Watcher, error) {
+	w := &Watcher{
+		path:   path,
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = DefaultInterval
+	}
+	if w.opts.Logger == nil {
+		w.opts.Logger = entry.NewLogEntry()
+	}
+	// a reload raising the minimum level must not hide its own report
+	w.logger = w.opts.Logger.WithMinLevel(enum.LevelTrace)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := config.SettingsFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w.base = config.GetConfig()
+	if err := config.ApplyOver(w.base, settings); err != nil {
+		return nil, err
+	}
+	w.settings, w.modTime, w.size = settings, info.ModTime(), info.Size()
+	w.reconfigureSinks()
+
+	go w.watch()
+	return w, nil
+}
+
+// Stop stops watching the file, it is safe to call more than once.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+	})
+	<-w.done
+}
+
+func (w *Watcher) watch() {
+	defer close(w.done)
+	ticker := customTime.GetClock().NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			w.check()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// check reloads the file when its modification time or size changed since the last check.
+func (w *Watcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return // the file may be in the middle of being replaced, it is picked up on a later check
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+	w.reload()
+}
+
+func (w *Watcher) reload() {
+	ctx := context.Background()
+	pathAttr := model.LogAttr{Key: "config_path", Value: w.path}
+	settings, err := config.SettingsFromFile(w.path)
+	if err == nil {
+		err = config.ApplyOver(w.base, settings)
+	}
+	if err != nil {
+		w.logger.Error(ctx, err, RejectedMsg, pathAttr)
+		return
+	}
+
+	previous := w.settings
+	changes := diff(previous, settings)
+	w.settings = settings
+	if settings.Output != previous.Output || settings.Buffer != previous.Buffer || settings.Rate != previous.Rate {
+		w.reconfigureSinks()
+	}
+	if len(changes) == 0 {
+		return
+	}
+	w.logger.Info(ctx, ReloadedMsg, pathAttr, model.LogAttr{Key: "changes", Value: strings.Join(changes, changesSeparator)})
+}
+
+func (w *Watcher) reconfigureSinks() {
+	cfg := config.GetConfig()
+	for _, sink := range w.opts.Sinks {
+		sink.Reconfigure(cfg.Rate(), cfg.LogBuffer(), cfg.Output())
+	}
+}
+
+// diff describes the settings of next that differ from previous, e.g. `level: "info" -> "warn"`.
+// Settings removed from the file are reported with an empty value, they are back to
+// their value before the file was first applied.
+func diff(previous, next config.Settings) []string {
+	var changes []string
+	add := func(name string, from, to any) {
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", name, fmt.Sprint(from), fmt.Sprint(to)))
+	}
+	if !strings.EqualFold(next.Level, previous.Level) {
+		add("level", previous.Level, next.Level)
+	}
+	if !strings.EqualFold(next.Encoder, previous.Encoder) {
+		add("encoder", previous.Encoder, next.Encoder)
+	}
+	if next.TimeFormat != previous.TimeFormat {
+		add("time_format", previous.TimeFormat, next.TimeFormat)
+	}
+	if next.TimeZone != previous.TimeZone {
+		add("time_zone", previous.TimeZone, next.TimeZone)
+	}
+	if next.Output != previous.Output {
+		add("output", previous.Output, next.Output)
+	}
+	if from, to := optionalBool(previous.AddSource), optionalBool(next.AddSource); from != to {
+		add("add_source", from, to)
+	}
+	if next.Buffer != previous.Buffer {
+		add("buffer", previous.Buffer, next.Buffer)
+	}
+	if next.Rate != previous.Rate {
+		add("rate", previous.Rate, next.Rate)
+	}
+	if !strings.EqualFold(next.KeyCollision, previous.KeyCollision) {
+		add("key_collision", previous.KeyCollision, next.KeyCollision)
+	}
+	changes = append(changes, diffMap("static_fields", previous.StaticFields, next.StaticFields)...)
+	changes = append(changes, diffMap("default_fields", previous.DefaultFields, next.DefaultFields)...)
+	return changes
+}
+
+// optionalBool formats an optional setting, an unset one is empty.
+func optionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprint(*b)
+}
+
+// diffMap describes the keys of next that differ from previous and the keys only in previous.
+func diffMap(name string, previous, next map[string]string) []string {
+	keys := make([]string, 0, len(next))
+	for key, value := range next {
+		if previous[key] != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range previous {
+		if _, exists := next[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	changes := make([]string, 0, len(keys))
+	for _, key := range keys {
+		changes = append(changes, fmt.Sprintf("%s.%s: %q -> %q", name, key, previous[key], next[key]))
+	}
+	return changes
+}
@@ -0,0 +1,187 @@
+package configWatcher
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPreProcessorObserver struct {
+	mu       sync.Mutex
+	logLevel []enum.LogLevel
+	logEntry []string
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logLevel = append(t.logLevel, level)
+	t.logEntry = append(t.logEntry, string(logMsg))
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+func (t *testPreProcessorObserver) find(substr string) (enum.LogLevel, string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, logMsg := range t.logEntry {
+		if strings.Contains(logMsg, substr) {
+			return t.logLevel[i], logMsg, true
+		}
+	}
+	return enum.LevelUnSet, "", false
+}
+
+type testSink struct {
+	mu     sync.Mutex
+	rate   time.Duration
+	buffer int
+	output io.Writer
+}
+
+func (s *testSink) Reconfigure(rate time.Duration, maxBufferSize int, output io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rate, s.buffer, s.output = rate, maxBufferSize, output
+}
+
+func (s *testSink) settings() (time.Duration, int, io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rate, s.buffer, s.output
+}
+
+func setup(t *testing.T) *testPreProcessorObserver {
+	cfg := config.GetConfig()
+	minLevel, encoderType, rate, buffer, output := cfg.MinLevel(), cfg.EncoderType(), cfg.Rate(), cfg.LogBuffer(), cfg.Output()
+	t.Cleanup(func() {
+		config.SetMinLevel(minLevel)
+		config.SetEncoderType(encoderType)
+		config.SetRate(rate)
+		config.SetLogBufferMaxSize(buffer)
+		config.SetOutput(output)
+	})
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+// writeConfig writes content to path and moves its modification time forward,
+// so the change is seen even on file systems with a coarse time resolution.
+func writeConfig(t *testing.T, path, content string, age time.Duration) {
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	modTime := time.Now().Add(-age)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestWatcherReloadsChangedFile(t *testing.T) {
+	observer := setup(t)
+	path := filepath.Join(t.TempDir(), "logging.yaml")
+	writeConfig(t, path, "level: info\nbuffer: 10\nrate: 1s\n", time.Hour)
+	sink := &testSink{}
+
+	w, err := Watch(path, &Options{Interval: 5 * time.Millisecond, Sinks: []Sink{sink}})
+	assert.NoError(t, err)
+	defer w.Stop()
+	assert.Equal(t, enum.LevelInfo, config.GetConfig().MinLevel())
+	_, buffer, _ := sink.settings()
+	assert.Equal(t, 10, buffer)
+
+	writeConfig(t, path, "level: debug\nbuffer: 25\nrate: 2s\noutput: stderr\n", 0)
+	assert.Eventually(t, func() bool {
+		_, _, found := observer.find(ReloadedMsg)
+		return found
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, enum.LevelDebug, config.GetConfig().MinLevel())
+	rate, buffer, output := sink.settings()
+	assert.Equal(t, 2*time.Second, rate)
+	assert.Equal(t, 25, buffer)
+	assert.Equal(t, os.Stderr, output)
+	level, logMsg, _ := observer.find(ReloadedMsg)
+	assert.Equal(t, enum.LevelInfo, level)
+	assert.Contains(t, logMsg, config.ParseLogField("config_path", path))
+	assert.Contains(t, logMsg, `level: \"info\" -> \"debug\"`)
+	assert.Contains(t, logMsg, `buffer: \"10\" -> \"25\"`)
+	assert.Contains(t, logMsg, `output: \"\" -> \"stderr\"`)
+}
+
+func TestWatcherRevertsRemovedSettings(t *testing.T) {
+	observer := setup(t)
+	config.SetMinLevel(enum.LevelDebug)
+	path := filepath.Join(t.TempDir(), "logging.yaml")
+	writeConfig(t, path, "level: info\nencoder: text\n", time.Hour)
+
+	w, err := Watch(path, &Options{Interval: 5 * time.Millisecond})
+	assert.NoError(t, err)
+	defer w.Stop()
+	assert.Equal(t, enum.EncoderText, config.GetConfig().EncoderType())
+
+	writeConfig(t, path, "level: warn\n", 0)
+	assert.Eventually(t, func() bool {
+		_, _, found := observer.find(ReloadedMsg)
+		return found
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, enum.LevelWarn, config.GetConfig().MinLevel())
+	assert.Equal(t, enum.EncoderJSON, config.GetConfig().EncoderType(), "the removed encoder is reverted")
+	level, logMsg, _ := observer.find(ReloadedMsg)
+	assert.Equal(t, enum.LevelInfo, level, "the reload is logged below the new minimum level")
+	assert.Contains(t, logMsg, `level: \"info\" -> \"warn\"`)
+	assert.Contains(t, logMsg, `encoder: \"text\" -> \"\"`)
+}
+
+func TestWatcherRejectsInvalidFile(t *testing.T) {
+	observer := setup(t)
+	path := filepath.Join(t.TempDir(), "logging.json")
+	writeConfig(t, path, `{"level": "debug"}`, time.Hour)
+
+	w, err := Watch(path, &Options{Interval: 5 * time.Millisecond})
+	assert.NoError(t, err)
+	defer w.Stop()
+
+	writeConfig(t, path, `{"level": "loud", "encoder": "text"}`, 0)
+	assert.Eventually(t, func() bool {
+		_, _, found := observer.find(RejectedMsg)
+		return found
+	}, time.Second, 5*time.Millisecond)
+
+	level, logMsg, _ := observer.find(RejectedMsg)
+	assert.Equal(t, enum.LevelError, level)
+	assert.Contains(t, logMsg, "loud")
+	assert.Equal(t, enum.LevelDebug, config.GetConfig().MinLevel(), "the previous config is kept")
+	assert.Equal(t, enum.EncoderJSON, config.GetConfig().EncoderType())
+}
+
+func TestWatchFailsOnInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.yaml")
+	writeConfig(t, path, "level: loud\n", 0)
+	_, err := Watch(path, nil)
+	assert.ErrorIs(t, err, config.ErrInvalidSetting)
+
+	_, err = Watch(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	addSource := true
+	changes := diff(
+		config.Settings{Level: "info", StaticFields: map[string]string{"service": "billing", "env": "dev"}},
+		config.Settings{Level: "INFO", AddSource: &addSource, StaticFields: map[string]string{"service": "billing"}, DefaultFields: map[string]string{"time": "ts"}},
+	)
+	assert.Equal(t, []string{
+		`add_source: "" -> "true"`,
+		`static_fields.env: "dev" -> ""`,
+		`default_fields.time: "" -> "ts"`,
+	}, changes)
+}
@@ -0,0 +1,66 @@
+package customTime
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of the time of the library, the timestamps of the entries,
+// the durations it measures and the tickers flushing the batched entries.
+// Tests set a fake clock to get stable timestamps and flush the batches when they choose.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on C like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// SystemClock is the Clock reading the system time, it is used by default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+var clock atomic.Pointer[Clock]
+
+// SetClock sets the clock used by the library, nil sets SystemClock back.
+// Tickers created before keep running on the clock that created them.
+func SetClock(c Clock) {
+	if c == nil {
+		c = SystemClock
+	}
+	clock.Store(&c)
+}
+
+// GetClock returns the clock used by the library.
+func GetClock() Clock {
+	if c := clock.Load(); c != nil {
+		return *c
+	}
+	return SystemClock
+}
+
+// Since returns the time elapsed since t on the clock used by the library.
+func Since(t time.Time) time.Duration {
+	return GetClock().Now().Sub(t)
+}
@@ -0,0 +1,93 @@
+package customTime
+
+import "time"
+
+// AppendDuration appends d formatted as by d.String() to dst, without allocating the string.
+func AppendDuration(dst []byte, d time.Duration) []byte {
+	var buf [32]byte
+	w := len(buf)
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+	if u < uint64(time.Second) {
+		// below a second the unit changes with the magnitude, e.g. "1.5ms" or "800ns"
+		var prec int
+		w--
+		buf[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			buf[w] = '0'
+			return append(dst, buf[w:]...)
+		case u < uint64(time.Microsecond):
+			buf[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			w--
+			copy(buf[w:], "µ")
+		default:
+			prec = 6
+			buf[w] = 'm'
+		}
+		w, u = formatFrac(buf[:w], u, prec)
+		w = formatInt(buf[:w], u)
+	} else {
+		w--
+		buf[w] = 's'
+		w, u = formatFrac(buf[:w], u, 9)
+		w = formatInt(buf[:w], u%60)
+		if u /= 60; u > 0 {
+			w--
+			buf[w] = 'm'
+			w = formatInt(buf[:w], u%60)
+			if u /= 60; u > 0 {
+				w--
+				buf[w] = 'h'
+				w = formatInt(buf[:w], u)
+			}
+		}
+	}
+	if neg {
+		w--
+		buf[w] = '-'
+	}
+	return append(dst, buf[w:]...)
+}
+
+// formatFrac formats the prec lowest digits of v as a fraction at the end of buf, trailing
+// zeros and a zero fraction left out, and returns the start of the fraction and v without them.
+func formatFrac(buf []byte, v uint64, prec int) (int, uint64) {
+	w := len(buf)
+	written := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		if written = written || digit != 0; written {
+			w--
+			buf[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if written {
+		w--
+		buf[w] = '.'
+	}
+	return w, v
+}
+
+// formatInt formats v at the end of buf and returns its start.
+func formatInt(buf []byte, v uint64) int {
+	w := len(buf)
+	if v == 0 {
+		w--
+		buf[w] = '0'
+		return w
+	}
+	for v > 0 {
+		w--
+		buf[w] = byte(v%10) + '0'
+		v /= 10
+	}
+	return w
+}
@@ -0,0 +1,23 @@
+package customTime
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendDurationMatchesString(t *testing.T) {
+	durations := []time.Duration{
+		0, 1, 999, time.Microsecond, 1500 * time.Nanosecond, time.Millisecond, 1500 * time.Microsecond,
+		time.Second, 1500 * time.Millisecond, time.Minute + 30*time.Second, 26*time.Hour + 3*time.Minute + 4*time.Second + 5,
+		-1500 * time.Millisecond, -time.Microsecond, math.MaxInt64, math.MinInt64,
+	}
+	for _, d := range durations {
+		assert.Equal(t, d.String(), string(AppendDuration([]byte("d="), d))[2:], int64(d))
+	}
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() {
+		_ = AppendDuration(make([]byte, 0, 32), 1500*time.Millisecond)
+	}))
+}
@@ -1,11 +1,200 @@
 package customTime
 
-import "time"
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
+// Formats writing the time as the number of seconds, milliseconds, microseconds
+// or nanoseconds since the Unix epoch instead of formatting it with a layout.
+const (
+	FormatUnix      = "unix"
+	FormatUnixMilli = "unix_ms"
+	FormatUnixMicro = "unix_us"
+	FormatUnixNano  = "unix_ns"
+)
+
+// IsEpochFormat reports whether format is one of the Unix epoch formats, their
+// times are numbers and are written without quotes.
+func IsEpochFormat(format string) bool {
+	switch format {
+	case FormatUnix, FormatUnixMilli, FormatUnixMicro, FormatUnixNano:
+		return true
+	}
+	return false
+}
+
+// TimeNow returns the current time of the clock used by the library in UTC.
 func TimeNow() time.Time {
-	return time.Now().UTC()
+	return GetClock().Now().UTC()
 }
 
 func Format(t time.Time, format string) string {
-	return t.Format(format)
+	return string(AppendFormat(nil, t, format))
+}
+
+// AppendFormat appends t formatted with format to dst, format is a layout or an epoch format.
+// The layout formatted up to the fractional seconds is reused for the times of the same second,
+// it is cached for each format and location.
+func AppendFormat(dst []byte, t time.Time, format string) []byte {
+	switch format {
+	case FormatUnix:
+		return strconv.AppendInt(dst, t.Unix(), 10)
+	case FormatUnixMilli:
+		return strconv.AppendInt(dst, t.UnixMilli(), 10)
+	case FormatUnixMicro:
+		return strconv.AppendInt(dst, t.UnixMicro(), 10)
+	case FormatUnixNano:
+		return strconv.AppendInt(dst, t.UnixNano(), 10)
+	}
+	slot := secondSlotFor(format, t.Location())
+	if slot == nil {
+		return t.AppendFormat(dst, format)
+	}
+	c := slot.last.Load()
+	if c == nil || c.sec != t.Unix() {
+		c = newSecondCache(t, format)
+		slot.last.Store(c)
+	}
+	if c.uncached {
+		return t.AppendFormat(dst, format)
+	}
+	dst = append(dst, c.prefix...)
+	if c.fraction != "" {
+		dst = appendFraction(dst, t.Nanosecond(), c.fraction)
+		dst = append(dst, c.suffix...)
+	}
+	return dst
+}
+
+// appendFraction appends the fractional seconds of nanos as the layout element fraction
+// does, e.g. ".000" keeps three digits and ".999" trims the trailing zeros and the separator.
+func appendFraction(dst []byte, nanos int, fraction string) []byte {
+	trim := fraction[1] == '9'
+	if trim && nanos == 0 {
+		return dst
+	}
+	var digits [9]byte
+	for i := len(digits) - 1; i >= 0; i-- {
+		digits[i] = byte('0' + nanos%10)
+		nanos /= 10
+	}
+	n := len(fraction) - 1
+	if trim {
+		for n > 0 && digits[n-1] == '0' {
+			n--
+		}
+	}
+	dst = append(dst, fraction[0])
+	return append(dst, digits[:n]...)
+}
+
+// maxSecondSlots bounds the format and location pairs whose second is cached,
+// the times of the pairs beyond it are formatted without a cache.
+const maxSecondSlots = 16
+
+// secondSlot caches the layout formatted for the second of the last time formatted
+// with format in loc, so times in other formats or locations do not evict it.
+type secondSlot struct {
+	format string
+	loc    *time.Location
+	last   atomic.Pointer[secondCache]
+}
+
+var (
+	secondSlotsMu sync.Mutex                    // serializes adding a slot
+	secondSlots   atomic.Pointer[[]*secondSlot] // replaced, never mutated, once published
+)
+
+// secondSlotFor returns the slot of format and loc, added on first use, or nil when maxSecondSlots are in use.
+func secondSlotFor(format string, loc *time.Location) *secondSlot {
+	if slot := findSecondSlot(secondSlots.Load(), format, loc); slot != nil {
+		return slot
+	}
+	secondSlotsMu.Lock()
+	defer secondSlotsMu.Unlock()
+	current := secondSlots.Load()
+	if slot := findSecondSlot(current, format, loc); slot != nil {
+		return slot
+	}
+	var slots []*secondSlot
+	if current != nil {
+		slots = *current
+	}
+	if len(slots) >= maxSecondSlots {
+		return nil
+	}
+	slot := &secondSlot{format: format, loc: loc}
+	slots = append(slots[:len(slots):len(slots)], slot)
+	secondSlots.Store(&slots)
+	return slot
+}
+
+func findSecondSlot(slots *[]*secondSlot, format string, loc *time.Location) *secondSlot {
+	if slots == nil {
+		return nil
+	}
+	for _, slot := range *slots {
+		if slot.loc == loc && slot.format == format {
+			return slot
+		}
+	}
+	return nil
+}
+
+// secondCache is a layout formatted for a second, without the fractional seconds
+// that are formatted for every time between prefix and suffix.
+type secondCache struct {
+	sec      int64
+	loc      *time.Location
+	prefix   []byte
+	fraction string // layout of the fractional seconds, empty when format has none
+	suffix   []byte
+	uncached bool // format cannot be split around a single fractional seconds element
+}
+
+// newSecondCache formats format for the second of t, the cache is marked uncached when
+// format cannot be split around a single fractional seconds element.
+func newSecondCache(t time.Time, format string) *secondCache {
+	c := &secondCache{sec: t.Unix(), loc: t.Location()}
+	start, end := fractionIndex(format)
+	if start < 0 {
+		c.prefix = t.AppendFormat(nil, format)
+	} else if next, _ := fractionIndex(format[end:]); next < 0 && end-start-1 <= 9 {
+		c.prefix = t.AppendFormat(nil, format[:start])
+		c.fraction = format[start:end]
+		c.suffix = t.AppendFormat(nil, format[end:])
+	} else {
+		c.uncached = true
+		return c
+	}
+	// checked on a time of the same second with a fraction, a split the time package reads differently shows
+	probe := time.Unix(c.sec, 123456789).In(c.loc)
+	split := append([]byte(nil), c.prefix...)
+	if c.fraction != "" {
+		split = append(appendFraction(split, probe.Nanosecond(), c.fraction), c.suffix...)
+	}
+	c.uncached = !bytes.Equal(split, probe.AppendFormat(nil, format))
+	return c
+}
+
+// fractionIndex returns the bounds of the first fractional seconds element of the layout,
+// e.g. ".000" or ",999", as recognized by the time package, or -1.
+func fractionIndex(layout string) (int, int) {
+	for i := 0; i+1 < len(layout); i++ {
+		if (layout[i] != '.' && layout[i] != ',') || (layout[i+1] != '0' && layout[i+1] != '9') {
+			continue
+		}
+		j := i + 1
+		for j < len(layout) && layout[j] == layout[i+1] {
+			j++
+		}
+		if j == len(layout) || layout[j] < '0' || layout[j] > '9' {
+			return i, j
+		}
+	}
+	return -1, -1
 }
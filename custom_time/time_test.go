@@ -0,0 +1,44 @@
+package customTime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendFormatCachesEachFormatAndLocation(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		paris = time.FixedZone("CET", 3600)
+	}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 120000000, time.UTC)
+	formats := []string{time.RFC3339, time.RFC3339Nano, "2006-01-02 15:04:05.000 MST", time.Kitchen}
+	for i := 0; i < 3; i++ {
+		for _, format := range formats {
+			for _, loc := range []*time.Location{time.UTC, paris} {
+				tt := at.Add(time.Duration(i) * 1500 * time.Millisecond).In(loc)
+				assert.Equal(t, tt.Format(format), Format(tt, format), "%s in %s", format, loc)
+			}
+		}
+	}
+
+	slot := secondSlotFor(time.RFC3339Nano, paris)
+	cached := slot.last.Load()
+	_ = AppendFormat(nil, at, time.RFC3339Nano)
+	_ = AppendFormat(nil, at.In(paris), time.RFC3339)
+	assert.Same(t, cached, slot.last.Load(), "other formats and locations do not evict the cache")
+}
+
+func BenchmarkAppendFormatAlternatingFormats(b *testing.B) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	local := at.In(time.FixedZone("IST", 5*3600+1800))
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		t := at.Add(time.Duration(i % 1000))
+		buf = AppendFormat(buf[:0], t, time.RFC3339Nano)
+		buf = AppendFormat(buf[:0], local.Add(time.Duration(i%1000)), time.RFC3339Nano)
+		buf = AppendFormat(buf[:0], t, "2006-01-02 15:04:05.000")
+	}
+}
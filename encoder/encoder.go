@@ -8,8 +8,11 @@ import (
 
 var ErrUnsupportedEncoderType = errors.New("unsupported encoder type")
 
+// Encoder wraps the formatted fields of an entry, the fields are appended
+// between AppendBegin and AppendEnd on the same buffer.
 type Encoder interface {
-	Write(string) ([]byte, error)
+	AppendBegin(dst []byte) []byte
+	AppendEnd(dst []byte) []byte
 }
 
 func DefaultEncoderFactory(encoderType enum.LogEncodeType) (Encoder, error) {
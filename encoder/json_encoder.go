@@ -1,22 +1,15 @@
 package encoder
 
-import (
-	"encoding/json"
-)
-
 func NewJSONEncoder() Encoder {
-	// Implementation of JSON encoder
-	return &JSONEncoder{
-		jsonFormatter: json.NewEncoder(nil), // Replace nil with actual output writer
-	}
+	return &JSONEncoder{}
 }
 
-type JSONEncoder struct {
-	jsonFormatter *json.Encoder
-}
+type JSONEncoder struct{}
 
-func (e *JSONEncoder) Write(entryData string) ([]byte, error) {
-	// Implementation of JSON encoding logic
+func (e *JSONEncoder) AppendBegin(dst []byte) []byte {
+	return append(dst, '{')
+}
 
-	return []byte("{" + entryData + "}"), nil
+func (e *JSONEncoder) AppendEnd(dst []byte) []byte {
+	return append(dst, '}')
 }
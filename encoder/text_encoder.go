@@ -1,13 +1,15 @@
 package encoder
 
 func NewTextEncoder() Encoder {
-	// Implementation of Text encoder
 	return &TextEncoder{}
 }
 
 type TextEncoder struct{}
 
-func (e *TextEncoder) Write(entryData string) ([]byte, error) {
-	// Implementation of Text encoding logic
-	return []byte(entryData), nil
+func (e *TextEncoder) AppendBegin(dst []byte) []byte {
+	return dst
+}
+
+func (e *TextEncoder) AppendEnd(dst []byte) []byte {
+	return dst
 }
@@ -3,30 +3,30 @@ package entry
 import (
 	"context"
 	"runtime"
-	"strings"
-	"sync"
+	"time"
 
+	"github.com/architagr/lognugget/buffer"
 	"github.com/architagr/lognugget/config"
 	customTime "github.com/architagr/lognugget/custom_time"
 	"github.com/architagr/lognugget/enum"
 	"github.com/architagr/lognugget/model"
 )
 
-var (
-	entryPool sync.Pool
-)
-
-func init() {
-	entryPool = sync.Pool{
-		New: func() any {
-			return initLogEntry()
-		},
-	}
-}
-
+// LogEntry is a long lived logger, it is safe for concurrent use and is never
+// pooled, so it can be stored and shared for the lifetime of the application.
+// The per call state of an entry lives in a pooled buffer that is handed over
+// to the pipeline by config.PublishLog, or to the sink, and freed once the entry was processed.
 type LogEntry struct {
 	// caller Calling method, with package name
 	caller *runtime.Frame // TODO: add a function to set caller from runtime.Caller
+	// sampler Drops part of the enabled entries, nil logs them all
+	sampler Sampler
+	// sink Receives the entries instead of the pipeline, nil uses the pipeline
+	sink Sink
+	// minLevel Overrides the minimum level of the config, enum.LevelUnSet uses the config
+	minLevel enum.LogLevel
+	// sync Processes and flushes the entries on the calling goroutine instead of the pipeline
+	sync bool
 }
 
 // implement a builder to duplicate an existing logEntry having below functions
@@ -36,68 +36,136 @@ type LogEntry struct {
 // WithTime
 
 func NewLogEntry() *LogEntry {
-	e := entryPool.Get().(*LogEntry)
-	e.reset()
-	return e
+	return &LogEntry{}
 }
 
-func GenerateInitialPool(n int) {
-	for i := 0; i < n; i++ {
-		entryPool.Put(initLogEntry())
-	}
-}
+// GenerateInitialPool does nothing, LogEntry is no longer pooled.
+//
+// Deprecated: a LogEntry is long lived, create it once with NewLogEntry and share it.
+func GenerateInitialPool(n int) {}
 
-func initLogEntry() *LogEntry {
-	return &LogEntry{}
-}
-func (e *LogEntry) reset() {
-	e.caller = nil
+// Put does nothing, LogEntry is no longer pooled.
+//
+// Deprecated: a LogEntry is long lived and is never returned to a pool.
+func (e *LogEntry) Put() {}
+
+// Enabled reports whether an entry at level would be logged.
+func Enabled(level enum.LogLevel) bool {
+	return config.GetConfig().MinLevel() <= level && config.PreProcessors() != nil
 }
 
-func (e *LogEntry) Put() {
-	entryPool.Put(e)
+// Enabled reports whether an entry at level would be logged by e.
+func (e *LogEntry) Enabled(level enum.LogLevel) bool {
+	if e.sink == nil && e.minLevel == enum.LevelUnSet {
+		return Enabled(level)
+	}
+	minLevel := e.minLevel
+	if minLevel == enum.LevelUnSet {
+		minLevel = config.GetConfig().MinLevel()
+	}
+	return minLevel <= level && (e.sink != nil || config.PreProcessors() != nil)
 }
 
 func (e *LogEntry) Log(level enum.LogLevel, ctx context.Context, message string, err error, fields ...model.LogAttr) {
-	if config.GetConfig().MinLevel() > level || config.EventPreProcessors == nil {
+	if !e.Enabled(level) {
 		return
 	}
+	e.LogAt(customTime.TimeNow(), level, ctx, message, err, fields...)
+}
 
-	defaultFields := config.GetConfig().DefaultFields()
-	ctxData := e.setLogContextFields(ctx)
-	data := make([]string, 3+len(fields)+len(ctxData), len(fields)+5)
-	i := 0
-	data[i] = config.ParseLogField(defaultFields[enum.DefaultLogKeyTime], customTime.Format(customTime.TimeNow(), config.GetConfig().TimeFormat()))
-	data[i+1] = config.ParseLogField(defaultFields[enum.DefaultLogKeyLevel], level.String())
-	data[i+2] = config.ParseLogField(defaultFields[enum.DefaultLogKeyMessage], message)
-	i += 2
-	for _, field := range fields {
-		if _, ok := defaultFields[enum.DefaultLogKey(field.Key)]; ok {
-			field.Key = model.LogAttrKey(config.DefaultPrefix) + field.Key
-		}
-		i++
-		data[i] = config.ParseLogField(string(field.Key), field.Value)
+// LogAt logs the entry with t as its time instead of the current time,
+// the time field is left out when t is the zero time.
+func (e *LogEntry) LogAt(t time.Time, level enum.LogLevel, ctx context.Context, message string, err error, fields ...model.LogAttr) {
+	if !e.Enabled(level) {
+		return
+	}
+	if e.sampler != nil && !e.sampler.Sample(level, message) {
+		return
 	}
 
-	for x, d := range ctxData {
-		data[i+x] = d
+	cfg := config.GetConfig()
+	defaultFields := cfg.DefaultFields()
+	replace := cfg.ReplaceAttr()
+	en := cfg.Encoder()
+	buf := buffer.Get()
+	data := en.AppendBegin(buf.B)
+	start := len(data)
+	if !t.IsZero() {
+		if replace != nil {
+			data = appendReplacedAttr(cfg, data, start, model.LogAttr{Key: model.LogAttrKey(defaultFields[enum.DefaultLogKeyTime]), Value: t})
+		} else {
+			data = cfg.AppendLogTimeField(data, defaultFields[enum.DefaultLogKeyTime], t)
+		}
+	}
+	if replace != nil {
+		data = appendReplacedAttr(cfg, data, start, model.LogAttr{Key: model.LogAttrKey(defaultFields[enum.DefaultLogKeyLevel]), Value: level})
+		data = appendReplacedAttr(cfg, data, start, model.LogAttr{Key: model.LogAttrKey(defaultFields[enum.DefaultLogKeyMessage]), Value: message})
+	} else {
+		data = config.AppendLogStringField(appendSeparator(data, start), defaultFields[enum.DefaultLogKeyLevel], level.String())
+		data = config.AppendLogStringField(appendSeparator(data, start), defaultFields[enum.DefaultLogKeyMessage], message)
+	}
+	if cfg.Redactor() != nil || replace != nil {
+		fields = processAttrs(cfg, fields, make([]model.LogAttr, 0, len(fields)))
 	}
+	fieldSet := newFieldSet(cfg, fields, e.contextAttrs(cfg, ctx))
+	data = fieldSet.append(data, start)
 	if err != nil {
-		data = append(data, config.ParseLogField(defaultFields[enum.DefaultLogKeyError], err.Error()))
+		var pcs [maxStackDepth]uintptr
+		var stack []uintptr
+		if level >= enum.LevelError && model.ErrorStack(err) == nil {
+			stack = logSiteStack(pcs[:])
+		}
+		data = appendError(cfg, data, start, defaultFields[enum.DefaultLogKeyError], err, stack)
 	}
 	if e.caller != nil {
-		data = append(data, config.ParseLogField(defaultFields[enum.DefaultLogKeyCaller], e.caller.Function))
+		if replace != nil {
+			data = appendReplacedAttr(cfg, data, start, model.LogAttr{Key: model.LogAttrKey(defaultFields[enum.DefaultLogKeyCaller]), Value: e.caller.Function})
+		} else {
+			data = config.AppendLogStringField(appendSeparator(data, start), defaultFields[enum.DefaultLogKeyCaller], e.caller.Function)
+		}
+	}
+	buf.B = en.AppendEnd(data)
+	switch {
+	case e.sink != nil:
+		e.sink.WriteEntry(level, buf.B)
+		buf.Free()
+	case e.sync:
+		config.ProcessLogSync(level, buf.B)
+		buf.Free()
+	default:
+		config.PublishLog(level, buf)
+	}
+}
+
+// appendSeparator adds the field separator unless nothing was written since start.
+func appendSeparator(data []byte, start int) []byte {
+	if len(data) > start {
+		return config.AppendFieldSeparator(data)
 	}
-	str := strings.Join(data, ", ")
-	if config.GetConfig().StaticFields() != "" {
-		str += ", " + config.GetConfig().StaticFields()
+	return data
+}
+
+// appendReplacedAttr appends the default field a as rewritten by the ReplaceAttr of cfg,
+// an attribute returned with an empty key is dropped and time values keep the configured time format.
+func appendReplacedAttr(cfg *config.Config, data []byte, start int, a model.LogAttr) []byte {
+	a = cfg.ReplaceAttr()(nil, a)
+	if a.Key == "" {
+		return data
 	}
+	return appendAttr(cfg, data, start, a.Key, model.Resolve(a.Value))
+}
 
-	en := config.GetConfig().Encoder()
-	byteData, _ := en.Write(str)
-	config.PublishLog(level, byteData)
+// appendAttr appends the resolved value under key, time values keep the configured time format and zone.
+func appendAttr(cfg *config.Config, data []byte, start int, key model.LogAttrKey, value any) []byte {
+	data = appendSeparator(data, start)
+	if t, ok := value.(time.Time); ok {
+		return cfg.AppendLogTimeField(data, string(key), t)
+	}
+	return config.AppendLogField(data, string(key), value)
+}
 
-	e.Put()
+func (e *LogEntry) Trace(ctx context.Context, message string, fields ...model.LogAttr) {
+	e.Log(enum.LevelTrace, ctx, message, nil, fields...)
 }
 
 func (e *LogEntry) Debug(ctx context.Context, message string, fields ...model.LogAttr) {
@@ -107,12 +175,18 @@ func (e *LogEntry) Debug(ctx context.Context, message string, fields ...model.Lo
 func (e *LogEntry) Info(ctx context.Context, message string, fields ...model.LogAttr) {
 	e.Log(enum.LevelInfo, ctx, message, nil, fields...)
 }
+func (e *LogEntry) Notice(ctx context.Context, message string, fields ...model.LogAttr) {
+	e.Log(enum.LevelNotice, ctx, message, nil, fields...)
+}
 func (e *LogEntry) Warn(ctx context.Context, message string, fields ...model.LogAttr) {
 	e.Log(enum.LevelWarn, ctx, message, nil, fields...)
 }
 func (e *LogEntry) Error(ctx context.Context, err error, message string, fields ...model.LogAttr) {
 	e.Log(enum.LevelError, ctx, message, err, fields...)
 }
+func (e *LogEntry) Critical(ctx context.Context, err error, message string, fields ...model.LogAttr) {
+	e.Log(enum.LevelCritical, ctx, message, err, fields...)
+}
 
 func (e *LogEntry) Fatal(ctx context.Context, err error, message string, fields ...model.LogAttr) {
 	e.Error(ctx, err, message, fields...)
@@ -123,13 +197,36 @@ func (e *LogEntry) Panic(ctx context.Context, err error, message string, fields
 	panic(err) // Panic with the error
 }
 
-func (e *LogEntry) setLogContextFields(ctx context.Context) []string {
-	if ctxParser := config.GetConfig().ContextParser(); ctx != nil && ctxParser != nil {
-		data := []string{}
-		for key, value := range ctxParser(ctx) {
-			data = append(data, config.ValidateandParseLogField(string(key), value))
+// contextAttrs returns the fields the context parser extracts from ctx, resolved, redacted and replaced.
+func (e *LogEntry) contextAttrs(cfg *config.Config, ctx context.Context) []model.LogAttr {
+	ctxParser := cfg.ContextParser()
+	if ctx == nil || ctxParser == nil {
+		return nil
+	}
+	values := ctxParser(ctx)
+	attrs := make([]model.LogAttr, 0, len(values))
+	for key, value := range values {
+		attrs = append(attrs, model.LogAttr{Key: model.LogAttrKey(key), Value: model.LogAttrValue(value)})
+	}
+	return processAttrs(cfg, attrs, attrs[:0])
+}
+
+// processAttrs appends to dst the attributes resolved, redacted and rewritten by the
+// ReplaceAttr of cfg, the attributes ReplaceAttr drops are left out. dst may share attrs.
+func processAttrs(cfg *config.Config, attrs []model.LogAttr, dst []model.LogAttr) []model.LogAttr {
+	redactor, replace := cfg.Redactor(), cfg.ReplaceAttr()
+	for _, a := range attrs {
+		a.Value = model.Resolve(a.Value)
+		if redactor != nil {
+			a = redactor.RedactAttr(a)
 		}
-		return data
+		if replace != nil {
+			if a = replace(nil, a); a.Key == "" {
+				continue
+			}
+			a.Value = model.Resolve(a.Value)
+		}
+		dst = append(dst, a)
 	}
-	return nil
+	return dst
 }
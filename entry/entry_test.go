@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,18 +17,27 @@ import (
 )
 
 type TestPreProcessorObserver struct {
+	mu            sync.Mutex
 	logEntry      []byte
 	isExecuted    bool
 	timeToProcess time.Duration
 }
 
 func (t *TestPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.isExecuted = true
 	n := time.Now()
-	t.logEntry = logMsg
+	t.logEntry = append([]byte(nil), logMsg...)
 	t.timeToProcess = time.Since(n)
 }
 
+func (t *TestPreProcessorObserver) result() (bool, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isExecuted, string(t.logEntry)
+}
+
 func (t *TestPreProcessorObserver) Name() string {
 	return "TestPreProcessorObserver"
 }
@@ -65,9 +76,9 @@ func TestEntryForDebugWithMinLogLevelAsDebug(t *testing.T) {
 	}...)
 	time.Sleep(10 * time.Millisecond)
 	defaultFields := config.GetConfig().DefaultFields()
-	logMsg := string(observer.logEntry)
+	isExecuted, logMsg := observer.result()
 	fmt.Println(logMsg)
-	assert.True(t, observer.isExecuted, "Pre processor should be executed for debug log when min log level is debug")
+	assert.True(t, isExecuted, "Pre processor should be executed for debug log when min log level is debug")
 	assert.Contains(t, logMsg, config.ParseLogField("app_name", "lognugget"), "Static field app_name should be set")
 	assert.Contains(t, logMsg, config.ParseLogField("version", "1.0.0"), "Static field version should be set")
 	assert.Contains(t, logMsg, config.ParseLogField("request_id", "12345"), "Context field request_id should be set")
@@ -112,7 +123,8 @@ func TestEntryForDebugWithMinLogLevelAsError(t *testing.T) {
 		{Key: model.LogAttrKey("message"), Value: model.LogAttrValue("message")},
 	}...)
 	time.Sleep(10 * time.Millisecond)
-	assert.False(t, observer.isExecuted, "Pre processor should not be executed for debug log when min log level is error")
+	isExecuted, _ := observer.result()
+	assert.False(t, isExecuted, "Pre processor should not be executed for debug log when min log level is error")
 }
 
 func TestEntryForErrorWithMinLogLevelAsDebug(t *testing.T) {
@@ -146,10 +158,10 @@ func TestEntryForErrorWithMinLogLevelAsDebug(t *testing.T) {
 	}...)
 	time.Sleep(10 * time.Millisecond)
 	defaultFields := config.GetConfig().DefaultFields()
-	logMsg := string(observer.logEntry)
-	assert.True(t, observer.isExecuted, "Pre processor should be executed for debug log when min log level is debug")
+	isExecuted, logMsg := observer.result()
+	assert.True(t, isExecuted, "Pre processor should be executed for debug log when min log level is debug")
 
-	assert.True(t, observer.isExecuted, "Pre processor should be executed for debug log when min log level is debug")
+	assert.True(t, isExecuted, "Pre processor should be executed for debug log when min log level is debug")
 	assert.Contains(t, logMsg, config.ParseLogField("app_name", "lognugget"), "Static field app_name should be set")
 	assert.Contains(t, logMsg, config.ParseLogField("version", "1.0.0"), "Static field version should be set")
 	assert.Contains(t, logMsg, config.ParseLogField("request_id", "12345"), "Context field request_id should be set")
@@ -159,6 +171,212 @@ func TestEntryForErrorWithMinLogLevelAsDebug(t *testing.T) {
 	assert.Contains(t, logMsg, defaultFields[enum.DefaultLogKeyTime], "Log entry should have a time field")
 	assert.Contains(t, logMsg, config.ParseLogField(defaultFields[enum.DefaultLogKeyLevel], enum.LevelError.String()), "Log level should match")
 	assert.NotContains(t, logMsg, "caller", "Log entry should have a caller field")
-	assert.Contains(t, logMsg, config.ParseLogField(defaultFields[enum.DefaultLogKeyError], "not found"), "Field error should be set")
+	assert.Contains(t, logMsg, `"`+defaultFields[enum.DefaultLogKeyError]+`": {"message": "not found", "type": "*errors.errorString", "stack": ["github.com/architagr/lognugget/entry.TestEntryForErrorWithMinLogLevelAsDebug `, "Field error should be set with the stack of the log site")
 	// assert.LessOrEqual(t, observer.timeToProcess.Microseconds(), int64(timeoutForSingleLogProcessing.Microseconds()), "Pre processor should process log entry within the timeout")
 }
+
+type noopPreProcessorObserver struct{}
+
+func (noopPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {}
+
+func (noopPreProcessorObserver) Name() string {
+	return "noopPreProcessorObserver"
+}
+
+func TestLogWithTypedFieldsDoesNotAllocate(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(nil)
+	config.SetStaticEnvFieldsParser(func() map[string]any {
+		return map[string]any{"app_name": "lognugget"}
+	})
+	config.InitPreProcessors(noopPreProcessorObserver{})
+	ctx := context.Background()
+	err := errors.New("not found")
+
+	entry := NewLogEntry()
+	allocs := testing.AllocsPerRun(1000, func() {
+		entry.Info(ctx, "user logged in",
+			model.LogAttr{Key: "user", Value: "alice"},
+			model.LogAttr{Key: "count", Value: 42},
+			model.LogAttr{Key: "admin", Value: true},
+		)
+	})
+	assert.Equal(t, float64(0), allocs, "Info with typed fields should not allocate")
+
+	allocs = testing.AllocsPerRun(1000, func() {
+		entry.Log(enum.LevelWarn, ctx, "user not found", err, model.LogAttr{Key: "user", Value: "alice"})
+	})
+	assert.Equal(t, float64(0), allocs, "An error with typed fields should not allocate")
+
+	allocs = testing.AllocsPerRun(1000, func() {
+		entry.Error(ctx, err, "user not found", model.LogAttr{Key: "user", Value: "alice"})
+	})
+	assert.LessOrEqual(t, allocs, float64(1), "Error capturing the stack of the log site should allocate at most once")
+
+	allocs = testing.AllocsPerRun(1000, func() {
+		entry.Debug(ctx, "below min level", model.LogAttr{Key: "user", Value: "alice"})
+	})
+	assert.Equal(t, float64(0), allocs, "Disabled level should not allocate")
+}
+
+type countingPreProcessorObserver struct {
+	mu      sync.Mutex
+	entries map[string]int
+}
+
+// PreProcess counts the entries logged by the test, ignoring the ones still
+// in flight from the previous tests.
+func (c *countingPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	if !strings.Contains(string(logMsg), "shared entry") {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[string(logMsg)]++
+}
+
+func (c *countingPreProcessorObserver) Name() string {
+	return "countingPreProcessorObserver"
+}
+
+func (c *countingPreProcessorObserver) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, v := range c.entries {
+		n += v
+	}
+	return n
+}
+
+func TestSharedEntryIsSafeForConcurrentUse(t *testing.T) {
+	config.SetMinLevel(enum.LevelDebug)
+	config.SetContextFieldsParser(nil)
+	config.SetStaticEnvFieldsParser(nil)
+	observer := &countingPreProcessorObserver{entries: map[string]int{}}
+	config.InitPreProcessors(observer)
+
+	const goroutines, logsPerGoroutine = 8, 200
+	entry := NewLogEntry()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < logsPerGoroutine; i++ {
+				entry.Info(context.Background(), "shared entry",
+					model.LogAttr{Key: "goroutine", Value: g},
+					model.LogAttr{Key: "i", Value: i},
+				)
+				entry.Debug(context.Background(), "shared entry at debug level")
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool { return observer.count() == 2*goroutines*logsPerGoroutine }, time.Second, 10*time.Millisecond)
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < logsPerGoroutine; i++ {
+			fields := config.ParseLogField("goroutine", g) + ", " + config.ParseLogField("i", i)
+			found := 0
+			for logMsg, n := range observer.entries {
+				if strings.Contains(logMsg, fields+"}") {
+					found += n
+				}
+			}
+			assert.Equal(t, 1, found, "every entry should be published exactly once and never overwritten")
+		}
+	}
+}
+
+func TestLazyFieldIsOnlyResolvedWhenLogged(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(nil)
+	config.SetStaticEnvFieldsParser(nil)
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	ctx := context.Background()
+	calls := 0
+	diff := model.Lazy("diff", func() any {
+		calls++
+		return "expensive"
+	})
+
+	entry := NewLogEntry()
+	entry.Debug(ctx, "below min level", diff)
+	assert.Equal(t, 0, calls, "a lazy field should not be resolved when the level is disabled")
+
+	entry.Info(ctx, "lazy message", diff)
+	assert.Equal(t, 1, calls)
+	assert.Eventually(t, func() bool {
+		_, logMsg := observer.result()
+		return strings.Contains(logMsg, config.ParseLogField("diff", "expensive"))
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestReplaceAttrRewritesEveryAttribute(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(func(ctx context.Context) map[string]any {
+		return map[string]any{"itrr": "10.0.0.1"}
+	})
+	config.SetStaticEnvFieldsParser(func() map[string]any {
+		return map[string]any{"version": "1.0.0", "itrr": "static"}
+	})
+	var groups [][]string
+	var mu sync.Mutex
+	config.SetReplaceAttr(func(g []string, a model.LogAttr) model.LogAttr {
+		mu.Lock()
+		groups = append(groups, g)
+		mu.Unlock()
+		switch value := a.Value.(type) {
+		case time.Time:
+			a.Value = value.UnixMilli()
+		case enum.LogLevel:
+			a.Key = "severity"
+		case string:
+			if len(value) > 8 {
+				a.Value = value[:8] + "..."
+			}
+		}
+		if a.Key == "itrr" {
+			return model.LogAttr{}
+		}
+		return a
+	})
+	defer func() {
+		config.SetReplaceAttr(nil)
+		config.SetContextFieldsParser(nil)
+		config.SetStaticEnvFieldsParser(nil)
+	}()
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	NewLogEntry().LogAt(at, enum.LevelWarn, context.Background(), "a rather long message", errors.New("not found"),
+		model.LogAttr{Key: "user", Value: "alice"},
+		model.LogAttr{Key: "itrr", Value: "dropped"},
+	)
+	assert.Eventually(t, func() bool {
+		isExecuted, _ := observer.result()
+		return isExecuted
+	}, time.Second, 5*time.Millisecond)
+	_, logMsg := observer.result()
+	assert.True(t, strings.HasPrefix(logMsg, "{"+config.ParseLogField("time", at.UnixMilli())+", "))
+	assert.Contains(t, logMsg, config.ParseLogField("severity", "WARN"))
+	assert.Contains(t, logMsg, config.ParseLogField("message", "a rather..."))
+	assert.Contains(t, logMsg, config.ParseLogField("user", "alice"))
+	assert.Contains(t, logMsg, config.ParseLogField("version", "1.0.0"))
+	assert.NotContains(t, logMsg, "itrr")
+	assert.NotContains(t, logMsg, ", ,")
+	assert.True(t, strings.HasSuffix(logMsg, `"error": {"message": "not found", "type": "*errors.errorString"}}`))
+	mu.Lock()
+	defer mu.Unlock()
+	for _, g := range groups {
+		assert.Nil(t, g)
+	}
+}
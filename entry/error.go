@@ -0,0 +1,63 @@
+package entry
+
+import (
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/model"
+)
+
+// appendError appends the error field, with stack as its stack trace when the error carries none,
+// and its messages and fields redacted by the redactor of cfg.
+// With a ReplaceAttr the field is appended as rewritten by it, keeping stack if it is still an error.
+func appendError(cfg *config.Config, data []byte, start int, key string, err error, stack []uintptr) []byte {
+	if replace := cfg.ReplaceAttr(); replace != nil {
+		a := replace(nil, model.LogAttr{Key: model.LogAttrKey(key), Value: err})
+		if a.Key == "" {
+			return data
+		}
+		value := model.Resolve(a.Value)
+		replaced, ok := value.(error)
+		if !ok {
+			return appendAttr(cfg, data, start, a.Key, value)
+		}
+		key, err = string(a.Key), replaced
+	}
+	return cfg.AppendErrorField(appendSeparator(data, start), key, err, stack)
+}
+
+// maxStackDepth bounds the frames captured at the log site.
+const maxStackDepth = 32
+
+// logSiteStack fills pcs with the stack of the caller of the logger, leaving out the
+// frames of this module, e.g. the LogEntry methods or a middleware, and of generated wrappers.
+func logSiteStack(pcs []uintptr) []uintptr {
+	n := runtime.Callers(2, pcs)
+	for n > 0 && loggerFrame(pcs[0]) {
+		pcs = pcs[1:n]
+		n--
+	}
+	return pcs[:n]
+}
+
+// loggerFrame reports whether pc is in the non test code of this module or in a generated
+// wrapper, runtime.Callers gives the frames of inlined calls their own pc.
+func loggerFrame(pc uintptr) bool {
+	fn := runtime.FuncForPC(pc - 1)
+	if fn == nil {
+		return false
+	}
+	file, _ := fn.FileLine(pc - 1)
+	if file == "<autogenerated>" {
+		return true
+	}
+	name := fn.Name()
+	return strings.HasPrefix(name, modulePath) && len(name) > len(modulePath) &&
+		(name[len(modulePath)] == '.' || name[len(modulePath)] == '/') && !strings.HasSuffix(file, "_test.go")
+}
+
+// modulePath is the import path of the module, the parent of the entry package.
+var modulePath = path.Dir(reflect.TypeOf(LogEntry{}).PkgPath())
@@ -0,0 +1,200 @@
+package entry
+
+import (
+	"strconv"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+// fieldSet holds the user, context and static fields of an entry in the order they
+// are written, and writes them following the key collision policy of the config.
+// A key collides when it is reserved by the logger or used by an earlier field.
+type fieldSet struct {
+	cfg      *config.Config
+	policy   enum.KeyCollisionPolicy
+	reserved []string
+	nestKey  string
+	user     []model.LogAttr
+	context  []model.LogAttr
+	static   []config.StaticField
+}
+
+func newFieldSet(cfg *config.Config, user, context []model.LogAttr) fieldSet {
+	f := fieldSet{
+		cfg:      cfg,
+		policy:   cfg.KeyCollisionPolicy(),
+		reserved: cfg.ReservedKeys(),
+		user:     user,
+		context:  context,
+		static:   cfg.StaticAttrs(),
+	}
+	if f.policy == enum.KeyCollisionNest {
+		f.nestKey = cfg.DefaultFields()[enum.DefaultLogKeyFields]
+	}
+	return f
+}
+
+func (f fieldSet) len() int {
+	return len(f.user) + len(f.context) + len(f.static)
+}
+
+func (f fieldSet) key(i int) string {
+	if i < len(f.user) {
+		return string(f.user[i].Key)
+	}
+	if i -= len(f.user); i < len(f.context) {
+		return string(f.context[i].Key)
+	}
+	return f.static[i-len(f.context)].Key
+}
+
+func (f fieldSet) isReserved(key string) bool {
+	for _, reserved := range f.reserved {
+		if key == reserved {
+			return true
+		}
+	}
+	return f.nestKey != "" && key == f.nestKey
+}
+
+// collisions counts how many times the key of field i was used before it, by the logger or earlier fields.
+func (f fieldSet) collisions(i int) int {
+	key := f.key(i)
+	n := 0
+	if f.isReserved(key) {
+		n++
+	}
+	for j := 0; j < i; j++ {
+		if f.key(j) == key {
+			n++
+		}
+	}
+	return n
+}
+
+// usedLater reports whether a field after i has the key of field i.
+func (f fieldSet) usedLater(i int) bool {
+	key := f.key(i)
+	for j := i + 1; j < f.len(); j++ {
+		if f.key(j) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// isUsed reports whether key is reserved, the key of any field or in renamed.
+func (f fieldSet) isUsed(key string, renamed []string) bool {
+	if f.isReserved(key) {
+		return true
+	}
+	for _, renamed := range renamed {
+		if key == renamed {
+			return true
+		}
+	}
+	for j := 0; j < f.len(); j++ {
+		if f.key(j) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueKey returns base, or base with the lowest counter from n on, that is not used by
+// any field or in renamed, the keys given to earlier colliding fields. The key is added to renamed.
+func (f fieldSet) uniqueKey(base string, n int, renamed *[]string) string {
+	key := base
+	if n > 0 {
+		key = base + "_" + strconv.Itoa(n)
+	}
+	for f.isUsed(key, *renamed) {
+		n++
+		key = base + "_" + strconv.Itoa(n)
+	}
+	*renamed = append(*renamed, key)
+	return key
+}
+
+func (f fieldSet) hasCollision() bool {
+	for i := 0; i < f.len(); i++ {
+		if f.collisions(i) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// appendField appends field i under key, the messages and fields of error values redacted.
+func (f fieldSet) appendField(data []byte, i int, key string) []byte {
+	var value any
+	if i < len(f.user) {
+		value = model.Resolve(f.user[i].Value)
+	} else if i -= len(f.user); i < len(f.context) {
+		value = f.context[i].Value
+	} else {
+		return config.AppendEncodedField(data, key, f.static[i-len(f.context)].Value)
+	}
+	if err, ok := value.(error); ok {
+		return f.cfg.AppendErrorField(data, key, err, nil)
+	}
+	return config.AppendLogField(data, key, value)
+}
+
+// append writes the fields after data[start:], separated from what was already written.
+func (f fieldSet) append(data []byte, start int) []byte {
+	if !f.hasCollision() {
+		for i := 0; i < len(f.user)+len(f.context); i++ {
+			data = f.appendField(appendSeparator(data, start), i, f.key(i))
+		}
+		if staticFields := f.cfg.StaticFields(); staticFields != "" {
+			data = append(appendSeparator(data, start), staticFields...)
+		}
+		return data
+	}
+
+	var nested []int
+	var renamed []string
+	for i := 0; i < f.len(); i++ {
+		key := f.key(i)
+		n := f.collisions(i)
+		switch {
+		case f.policy == enum.KeyCollisionLastWins && (f.usedLater(i) || f.isReserved(key)):
+			continue
+		case n == 0, f.policy == enum.KeyCollisionLastWins:
+		case f.policy == enum.KeyCollisionFirstWins:
+			continue
+		case f.policy == enum.KeyCollisionNest:
+			nested = append(nested, i)
+			continue
+		case f.policy == enum.KeyCollisionSuffix:
+			key = f.uniqueKey(key, n, &renamed)
+		default:
+			key = f.uniqueKey(config.DefaultPrefix+key, n-1, &renamed)
+		}
+		data = f.appendField(appendSeparator(data, start), i, key)
+	}
+	if len(nested) > 0 {
+		data = config.AppendLogGroupBegin(appendSeparator(data, start), f.nestKey)
+		for k, i := range nested {
+			if k > 0 {
+				data = config.AppendFieldSeparator(data)
+			}
+			key := f.key(i)
+			duplicates := 0
+			for _, j := range nested[:k] {
+				if f.key(j) == key {
+					duplicates++
+				}
+			}
+			if duplicates > 0 {
+				key += "_" + strconv.Itoa(duplicates)
+			}
+			data = f.appendField(data, i, key)
+		}
+		data = config.AppendLogGroupEnd(data)
+	}
+	return data
+}
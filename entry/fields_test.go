@@ -0,0 +1,106 @@
+package entry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyCollisionPolicies(t *testing.T) {
+	tests := []struct {
+		policy   enum.KeyCollisionPolicy
+		expected string
+	}{
+		{
+			policy:   enum.KeyCollisionPrefix,
+			expected: `"custom.level": "x", "user": "a", "custom.user": "b", "service": "f", "custom.user_1": "ctx", "custom.service": "s"`,
+		},
+		{
+			policy:   enum.KeyCollisionSuffix,
+			expected: `"level_1": "x", "user": "a", "user_1": "b", "service": "f", "user_2": "ctx", "service_1": "s"`,
+		},
+		{
+			policy:   enum.KeyCollisionLastWins,
+			expected: `"user": "ctx", "service": "s"`,
+		},
+		{
+			policy:   enum.KeyCollisionFirstWins,
+			expected: `"user": "a", "service": "f"`,
+		},
+		{
+			policy:   enum.KeyCollisionNest,
+			expected: `"user": "a", "service": "f", "fields": {"level": "x", "user": "b", "user_1": "ctx", "service": "s"}`,
+		},
+	}
+
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(func(ctx context.Context) map[string]any {
+		return map[string]any{"user": "ctx"}
+	})
+	config.SetStaticEnvFieldsParser(func() map[string]any {
+		return map[string]any{"service": "s"}
+	})
+	defer func() {
+		config.SetKeyCollisionPolicy(config.DefaultKeyCollision)
+		config.SetContextFieldsParser(nil)
+		config.SetStaticEnvFieldsParser(nil)
+	}()
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			config.SetKeyCollisionPolicy(test.policy)
+			observer := &TestPreProcessorObserver{}
+			config.InitPreProcessors(observer)
+
+			NewLogEntry().LogAt(time.Time{}, enum.LevelInfo, context.Background(), "hello", nil,
+				model.LogAttr{Key: "level", Value: "x"},
+				model.LogAttr{Key: "user", Value: "a"},
+				model.LogAttr{Key: "user", Value: "b"},
+				model.LogAttr{Key: "service", Value: "f"},
+			)
+			assert.Eventually(t, func() bool {
+				isExecuted, _ := observer.result()
+				return isExecuted
+			}, time.Second, 5*time.Millisecond)
+			_, logMsg := observer.result()
+			assert.Equal(t, `{"level": "INFO", "message": "hello", `+test.expected+`}`, logMsg)
+		})
+	}
+}
+
+func TestKeyCollisionPolicyKeepsEntriesWithoutCollision(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetKeyCollisionPolicy(enum.KeyCollisionNest)
+	config.SetStaticEnvFieldsParser(func() map[string]any {
+		return map[string]any{"service": "s"}
+	})
+	defer func() {
+		config.SetKeyCollisionPolicy(config.DefaultKeyCollision)
+		config.SetStaticEnvFieldsParser(nil)
+	}()
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+
+	NewLogEntry().LogAt(time.Time{}, enum.LevelInfo, context.Background(), "hello", nil, model.LogAttr{Key: "user", Value: "a"})
+	assert.Eventually(t, func() bool {
+		isExecuted, _ := observer.result()
+		return isExecuted
+	}, time.Second, 5*time.Millisecond)
+	_, logMsg := observer.result()
+	assert.Equal(t, `{"level": "INFO", "message": "hello", "user": "a", "service": "s"}`, logMsg)
+}
+
+func TestSetKeyCollisionPolicyIgnoresUnknownPolicy(t *testing.T) {
+	config.SetKeyCollisionPolicy(enum.KeyCollisionSuffix)
+	defer config.SetKeyCollisionPolicy(config.DefaultKeyCollision)
+
+	config.SetKeyCollisionPolicy("overwrite")
+	assert.Equal(t, enum.KeyCollisionSuffix, config.GetConfig().KeyCollisionPolicy())
+}
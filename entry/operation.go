@@ -0,0 +1,53 @@
+package entry
+
+import (
+	"context"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const (
+	DefaultOperationMsg  = "operation finished"
+	OperationStatusOK    = "ok"
+	OperationStatusError = "error"
+)
+
+// OperationDone finishes the operation started by StartOperation, err is the outcome of the operation.
+type OperationDone func(err error)
+
+// StartOperation starts timing the operation name and returns the function logging its end:
+//
+//	done := logger.StartOperation(ctx, "charge_card", model.LogAttr{Key: "order_id", Value: id})
+//	err := charge(ctx, id)
+//	done(err)
+//
+// The entry has the operation, duration and status (ok or error) fields, the fields
+// given here and the error. It is logged at Info level, or Error level when err is not nil.
+func (e *LogEntry) StartOperation(ctx context.Context, name string, fields ...model.LogAttr) OperationDone {
+	start := customTime.TimeNow()
+	return func(err error) {
+		duration := customTime.Since(start)
+		level, status := enum.LevelInfo, OperationStatusOK
+		if err != nil {
+			level, status = enum.LevelError, OperationStatusError
+		}
+		if !e.Enabled(level) {
+			return
+		}
+		e.Log(level, ctx, DefaultOperationMsg, err, operationFields(name, duration, status, fields...)...)
+	}
+}
+
+// operationFields returns the operation, duration and status fields followed by fields.
+func operationFields(name string, duration time.Duration, status string, fields ...model.LogAttr) []model.LogAttr {
+	keys := config.GetConfig().DefaultFields()
+	return append([]model.LogAttr{
+		{Key: model.LogAttrKey(keys[enum.DefaultLogKeyOperation]), Value: model.LogAttrValue(name)},
+		{Key: model.LogAttrKey(keys[enum.DefaultLogKeyDuration]), Value: model.LogAttrValue(duration)},
+		{Key: model.LogAttrKey(keys[enum.DefaultLogKeyStatus]), Value: model.LogAttrValue(status)},
+	}, fields...)
+}
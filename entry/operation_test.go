@@ -0,0 +1,70 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartOperation(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(nil)
+	config.SetStaticEnvFieldsParser(nil)
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	entry := NewLogEntry()
+	waitForEntry := func() string {
+		assert.Eventually(t, func() bool {
+			isExecuted, _ := observer.result()
+			return isExecuted
+		}, time.Second, 5*time.Millisecond)
+		_, logMsg := observer.result()
+		return logMsg
+	}
+
+	done := entry.StartOperation(context.Background(), "charge_card", model.LogAttr{Key: "order_id", Value: 7})
+	time.Sleep(5 * time.Millisecond)
+	done(nil)
+	logMsg := waitForEntry()
+	assert.Contains(t, logMsg, config.ParseLogField("level", "INFO"))
+	assert.Contains(t, logMsg, config.ParseLogField("message", DefaultOperationMsg))
+	assert.Contains(t, logMsg, config.ParseLogField("operation", "charge_card"))
+	assert.Contains(t, logMsg, config.ParseLogField("status", OperationStatusOK))
+	assert.Contains(t, logMsg, config.ParseLogField("order_id", 7))
+	assert.NotContains(t, logMsg, `"error"`)
+	match := regexp.MustCompile(`"duration": "([^"]+)"`).FindStringSubmatch(logMsg)
+	if assert.Len(t, match, 2) {
+		duration, err := time.ParseDuration(match[1])
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, duration, 5*time.Millisecond)
+	}
+
+	observer = &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	done = entry.StartOperation(context.Background(), "charge_card")
+	done(errors.New("card declined"))
+	logMsg = waitForEntry()
+	assert.Contains(t, logMsg, config.ParseLogField("level", "ERROR"))
+	assert.Contains(t, logMsg, config.ParseLogField("status", OperationStatusError))
+	assert.Contains(t, logMsg, `"error": {"message": "card declined"`)
+}
+
+func TestStartOperationAtDisabledLevel(t *testing.T) {
+	config.SetMinLevel(enum.LevelWarn)
+	defer config.SetMinLevel(enum.LevelInfo)
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+
+	NewLogEntry().StartOperation(context.Background(), "sync")(nil)
+	config.Flush()
+	isExecuted, _ := observer.result()
+	assert.False(t, isExecuted, "a successful operation is logged at Info level")
+}
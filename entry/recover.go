@@ -0,0 +1,95 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const DefaultRecoverMsg = "panic recovered"
+
+// RecoverOptions are the options of Recover, nil logs at Error level and does not re-panic.
+type RecoverOptions struct {
+	Level   enum.LogLevel   // Level of the entry, defaults to enum.LevelError
+	Message string          // Message of the entry, defaults to DefaultRecoverMsg
+	Fields  []model.LogAttr // Fields added to the entry
+	RePanic bool            // Panic again with the recovered value once the entry was written
+}
+
+// PanicError is the error logged for a recovered panic, Value is the value passed to panic.
+type PanicError struct {
+	Value any
+	stack []uintptr
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace returns the stack of the goroutine that panicked, from the frame calling panic.
+func (e *PanicError) StackTrace() []uintptr {
+	return e.stack
+}
+
+// NewPanicError returns the error of the recovered value r with the stack of the goroutine that
+// panicked, it is called by a deferred function that recovered r.
+func NewPanicError(r any) *PanicError {
+	return &PanicError{Value: r, stack: panicStack()}
+}
+
+// panicStack returns the stack of the goroutine running a deferred function,
+// from the frame calling panic when the goroutine is panicking.
+func panicStack() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	for i, pc := range pcs {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			return pcs[i+1:]
+		}
+	}
+	return pcs
+}
+
+// Recover recovers a panic when it is deferred, e.g. defer logger.Recover(ctx, nil), and logs
+// the panic value with the context fields, the stack of the error is the one of the panicking goroutine. The entry is flushed
+// to the output before Recover returns or, with RePanic, panics again with the same value.
+func (e *LogEntry) Recover(ctx context.Context, opts *RecoverOptions) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	o := RecoverOptions{Level: enum.LevelError, Message: DefaultRecoverMsg}
+	if opts != nil {
+		if opts.Level != enum.LevelUnSet {
+			o.Level = opts.Level
+		}
+		if opts.Message != "" {
+			o.Message = opts.Message
+		}
+		o.Fields, o.RePanic = opts.Fields, opts.RePanic
+	}
+
+	e.Log(o.Level, ctx, o.Message, NewPanicError(r), o.Fields...)
+	config.Flush()
+	if o.RePanic {
+		panic(r)
+	}
+}
+
+// Go runs fn in a new goroutine, a panic in fn is recovered and logged by Recover.
+func (e *LogEntry) Go(ctx context.Context, fn func()) {
+	go func() {
+		defer e.Recover(ctx, nil)
+		fn()
+	}()
+}
@@ -0,0 +1,92 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRecover(t *testing.T) *TestPreProcessorObserver {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(func(ctx context.Context) map[string]any {
+		return map[string]any{"request_id": ctx.Value("request_id")}
+	})
+	t.Cleanup(func() { config.SetContextFieldsParser(nil) })
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+func TestRecoverLogsAndFlushesThePanic(t *testing.T) {
+	observer := setupRecover(t)
+	ctx := context.WithValue(context.Background(), "request_id", "42")
+
+	func() {
+		defer NewLogEntry().Recover(ctx, nil)
+		panic("boom")
+	}()
+
+	isExecuted, logMsg := observer.result()
+	assert.True(t, isExecuted, "the entry is flushed before Recover returns")
+	assert.Contains(t, logMsg, config.ParseLogField("level", "ERROR"))
+	assert.Contains(t, logMsg, config.ParseLogField("message", DefaultRecoverMsg))
+	assert.Contains(t, logMsg, config.ParseLogField("request_id", "42"))
+	assert.Contains(t, logMsg, `"error": {"message": "panic: boom", "type": "*entry.PanicError"`)
+	assert.Contains(t, logMsg, `"stack": ["github.com/architagr/lognugget/entry.TestRecoverLogsAndFlushesThePanic.func1 `, "the stack starts at the panic")
+	assert.Equal(t, 1, strings.Count(logMsg, `"stack"`), "the panic is logged with a single stack")
+}
+
+func TestRecoverWithOptions(t *testing.T) {
+	observer := setupRecover(t)
+	cause := errors.New("closed")
+	opts := &RecoverOptions{
+		Level:   enum.LevelFatal,
+		Message: "worker crashed",
+		Fields:  []model.LogAttr{{Key: "worker", Value: 3}},
+		RePanic: true,
+	}
+
+	assert.PanicsWithValue(t, cause, func() {
+		defer NewLogEntry().Recover(context.Background(), opts)
+		panic(cause)
+	})
+
+	_, logMsg := observer.result()
+	assert.Contains(t, logMsg, config.ParseLogField("level", "FATAL"))
+	assert.Contains(t, logMsg, config.ParseLogField("message", "worker crashed"))
+	assert.Contains(t, logMsg, config.ParseLogField("worker", 3))
+	assert.Contains(t, logMsg, `{"message": "closed", "type": "*errors.errorString"}`, "the error chain holds the panic value")
+	assert.Len(t, opts.Fields, 1, "the fields of the options are not modified")
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	observer := setupRecover(t)
+	func() {
+		defer NewLogEntry().Recover(context.Background(), nil)
+	}()
+	isExecuted, _ := observer.result()
+	assert.False(t, isExecuted)
+}
+
+func TestGoRecoversThePanic(t *testing.T) {
+	observer := setupRecover(t)
+	ctx := context.WithValue(context.Background(), "request_id", "7")
+
+	NewLogEntry().Go(ctx, func() {
+		panic(errors.New("nil map"))
+	})
+	assert.Eventually(t, func() bool {
+		_, logMsg := observer.result()
+		return strings.Contains(logMsg, `"error": {"message": "panic: nil map"`)
+	}, time.Second, 5*time.Millisecond)
+	_, logMsg := observer.result()
+	assert.Contains(t, logMsg, config.ParseLogField("request_id", "7"))
+}
@@ -0,0 +1,47 @@
+package entry
+
+import (
+	"sync/atomic"
+
+	"github.com/architagr/lognugget/enum"
+)
+
+// Sampler decides whether an enabled entry is logged, it is called after the
+// level check and must be safe for concurrent use.
+type Sampler interface {
+	Sample(level enum.LogLevel, message string) bool
+}
+
+// SamplerFunc is a function used as a Sampler.
+type SamplerFunc func(level enum.LogLevel, message string) bool
+
+func (f SamplerFunc) Sample(level enum.LogLevel, message string) bool {
+	return f(level, message)
+}
+
+// everyNSampler logs the first of every n entries below maxLevel, entries at or above it are always logged.
+type everyNSampler struct {
+	n        uint64
+	maxLevel enum.LogLevel
+	count    atomic.Uint64
+}
+
+// EveryN returns a Sampler that logs one of every n entries below maxLevel,
+// entries at maxLevel or above are always logged. An n below 2 logs every entry.
+func EveryN(n uint64, maxLevel enum.LogLevel) Sampler {
+	return &everyNSampler{n: n, maxLevel: maxLevel}
+}
+
+func (s *everyNSampler) Sample(level enum.LogLevel, _ string) bool {
+	if level >= s.maxLevel || s.n < 2 {
+		return true
+	}
+	return (s.count.Add(1)-1)%s.n == 0
+}
+
+// WithSampler returns a copy of the entry that only logs the entries sampler keeps, a nil sampler keeps every entry.
+func (e *LogEntry) WithSampler(sampler Sampler) *LogEntry {
+	e2 := *e
+	e2.sampler = sampler
+	return &e2
+}
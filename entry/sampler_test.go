@@ -0,0 +1,26 @@
+package entry
+
+import (
+	"testing"
+
+	"github.com/architagr/lognugget/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryN(t *testing.T) {
+	sampler := EveryN(3, enum.LevelWarn)
+	var kept []bool
+	for i := 0; i < 6; i++ {
+		kept = append(kept, sampler.Sample(enum.LevelInfo, "message"))
+	}
+	assert.Equal(t, []bool{true, false, false, true, false, false}, kept)
+	assert.True(t, sampler.Sample(enum.LevelWarn, "message"))
+	assert.True(t, EveryN(1, enum.LevelWarn).Sample(enum.LevelDebug, "message"))
+}
+
+func TestWithSamplerDoesNotChangeTheEntry(t *testing.T) {
+	e := NewLogEntry()
+	sampled := e.WithSampler(SamplerFunc(func(enum.LogLevel, string) bool { return false }))
+	assert.Nil(t, e.sampler)
+	assert.NotNil(t, sampled.sampler)
+}
@@ -0,0 +1,37 @@
+package entry
+
+import "github.com/architagr/lognugget/enum"
+
+// Sink receives the encoded entries of a LogEntry set up with WithSink on the
+// goroutine logging them, instead of the pipeline. entry is only valid until
+// WriteEntry returns and must be copied to be retained.
+type Sink interface {
+	WriteEntry(level enum.LogLevel, entry []byte)
+}
+
+// WithSink returns a copy of the entry writing its entries to sink instead of
+// handing them to the pipeline, a nil sink hands them to the pipeline again.
+func (e *LogEntry) WithSink(sink Sink) *LogEntry {
+	e2 := *e
+	e2.sink = sink
+	return &e2
+}
+
+// WithSync returns a copy of the entry that, when sync is true, hands its entries to the
+// pre processors on the calling goroutine, see config.ProcessLogSync, so the entry is written before
+// the logging call returns, with the same bytes as through the pipeline. The batches of the
+// other loggers are not flushed. The hooks are then called from the logging goroutines and
+// must be safe for concurrent use.
+func (e *LogEntry) WithSync(sync bool) *LogEntry {
+	e2 := *e
+	e2.sync = sync
+	return &e2
+}
+
+// WithMinLevel returns a copy of the entry logging the entries at level or above
+// instead of those at the minimum level of the config, enum.LevelUnSet uses the config again.
+func (e *LogEntry) WithMinLevel(level enum.LogLevel) *LogEntry {
+	e2 := *e
+	e2.minLevel = level
+	return &e2
+}
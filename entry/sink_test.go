@@ -0,0 +1,51 @@
+package entry
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSink struct {
+	mu      sync.Mutex
+	levels  []enum.LogLevel
+	entries []string
+}
+
+func (s *testSink) WriteEntry(level enum.LogLevel, entry []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.levels = append(s.levels, level)
+	s.entries = append(s.entries, string(entry))
+}
+
+func TestWithSinkWritesOnTheCallingGoroutine(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	sink := &testSink{}
+	entry := NewLogEntry().WithSink(sink).WithMinLevel(enum.LevelDebug)
+
+	entry.Trace(context.Background(), "below the min level of the entry")
+	entry.Debug(context.Background(), "written")
+	assert.Equal(t, []enum.LogLevel{enum.LevelDebug}, sink.levels, "the entry is written before Debug returns")
+	assert.Contains(t, sink.entries[0], config.ParseLogField("message", "written"))
+
+	assert.True(t, NewLogEntry().WithSink(sink).Enabled(enum.LevelInfo))
+	assert.False(t, NewLogEntry().WithSink(sink).Enabled(enum.LevelDebug), "the min level of the config is used by default")
+}
+
+func TestWithSyncRunsThePreProcessorsOnTheCallingGoroutine(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+
+	NewLogEntry().WithSync(true).Info(context.Background(), "written")
+	isExecuted, logMsg := observer.result()
+	assert.True(t, isExecuted, "the entry is processed before Info returns")
+	assert.Contains(t, logMsg, config.ParseLogField("message", "written"))
+}
@@ -0,0 +1,83 @@
+package entry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+// badKey is the key of values in a key value list that have no string key before them.
+const badKey = "!BADKEY"
+
+// Logf logs the message formatted with fmt.Sprintf, the message is only formatted when level is enabled.
+func (e *LogEntry) Logf(level enum.LogLevel, ctx context.Context, err error, format string, args ...any) {
+	if !e.Enabled(level) {
+		return
+	}
+	e.Log(level, ctx, fmt.Sprintf(format, args...), err)
+}
+
+func (e *LogEntry) Debugf(ctx context.Context, format string, args ...any) {
+	e.Logf(enum.LevelDebug, ctx, nil, format, args...)
+}
+
+func (e *LogEntry) Infof(ctx context.Context, format string, args ...any) {
+	e.Logf(enum.LevelInfo, ctx, nil, format, args...)
+}
+
+func (e *LogEntry) Warnf(ctx context.Context, format string, args ...any) {
+	e.Logf(enum.LevelWarn, ctx, nil, format, args...)
+}
+
+func (e *LogEntry) Errorf(ctx context.Context, err error, format string, args ...any) {
+	e.Logf(enum.LevelError, ctx, err, format, args...)
+}
+
+// Logw logs the message with loosely typed key value pairs, see KeyValuesToAttrs.
+func (e *LogEntry) Logw(level enum.LogLevel, ctx context.Context, err error, message string, keyValues ...any) {
+	if !e.Enabled(level) {
+		return
+	}
+	e.Log(level, ctx, message, err, KeyValuesToAttrs(keyValues...)...)
+}
+
+func (e *LogEntry) Debugw(ctx context.Context, message string, keyValues ...any) {
+	e.Logw(enum.LevelDebug, ctx, nil, message, keyValues...)
+}
+
+func (e *LogEntry) Infow(ctx context.Context, message string, keyValues ...any) {
+	e.Logw(enum.LevelInfo, ctx, nil, message, keyValues...)
+}
+
+func (e *LogEntry) Warnw(ctx context.Context, message string, keyValues ...any) {
+	e.Logw(enum.LevelWarn, ctx, nil, message, keyValues...)
+}
+
+func (e *LogEntry) Errorw(ctx context.Context, err error, message string, keyValues ...any) {
+	e.Logw(enum.LevelError, ctx, err, message, keyValues...)
+}
+
+// KeyValuesToAttrs converts alternating keys and values into attributes.
+// A model.LogAttr in the list is used as is, a value without a string key
+// before it (including a trailing key with no value) is logged under "!BADKEY".
+func KeyValuesToAttrs(keyValues ...any) []model.LogAttr {
+	attrs := make([]model.LogAttr, 0, (len(keyValues)+1)/2)
+	for i := 0; i < len(keyValues); i++ {
+		switch key := keyValues[i].(type) {
+		case model.LogAttr:
+			attrs = append(attrs, key)
+		case string:
+			if i+1 == len(keyValues) {
+				attrs = append(attrs, model.LogAttr{Key: badKey, Value: model.LogAttrValue(key)})
+				continue
+			}
+			i++
+			attrs = append(attrs, model.LogAttr{Key: model.LogAttrKey(key), Value: model.LogAttrValue(keyValues[i])})
+		default:
+			attrs = append(attrs, model.LogAttr{Key: badKey, Value: model.LogAttrValue(key)})
+		}
+	}
+	return attrs
+}
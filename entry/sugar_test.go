@@ -0,0 +1,68 @@
+package entry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyValuesToAttrs(t *testing.T) {
+	attrs := KeyValuesToAttrs("user", "alice", model.LogAttr{Key: "id", Value: 1}, "count", 3)
+	assert.Equal(t, []model.LogAttr{
+		{Key: "user", Value: "alice"},
+		{Key: "id", Value: 1},
+		{Key: "count", Value: 3},
+	}, attrs)
+}
+
+func TestKeyValuesToAttrsWithBadKeys(t *testing.T) {
+	assert.Equal(t, []model.LogAttr{
+		{Key: badKey, Value: 42},
+		{Key: "user", Value: "alice"},
+		{Key: badKey, Value: "dangling"},
+	}, KeyValuesToAttrs(42, "user", "alice", "dangling"))
+	assert.Empty(t, KeyValuesToAttrs())
+}
+
+func TestSugaredMethods(t *testing.T) {
+	config.SetMinLevel(enum.LevelInfo)
+	config.SetEncoderType(enum.EncoderJSON)
+	config.SetContextFieldsParser(nil)
+	config.SetStaticEnvFieldsParser(nil)
+	observer := &TestPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	ctx := context.Background()
+	waitFor := func(field string) {
+		assert.Eventually(t, func() bool {
+			_, logMsg := observer.result()
+			return strings.Contains(logMsg, field)
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	entry := NewLogEntry()
+	entry.Infof(ctx, "user %s logged in %d times", "alice", 3)
+	waitFor(config.ParseLogField("message", "user alice logged in 3 times"))
+
+	entry.Errorf(ctx, errors.New("not found"), "lookup of %q failed", "bob")
+	waitFor(`"error": {"message": "not found"`)
+	_, logMsg := observer.result()
+	assert.Contains(t, logMsg, config.ParseLogField("message", "lookup of \"bob\" failed"))
+
+	entry.Infow(ctx, "sugared", "user", "alice", "odd")
+	waitFor(config.ParseLogField("message", "sugared"))
+	_, logMsg = observer.result()
+	assert.Contains(t, logMsg, config.ParseLogField("user", "alice"))
+	assert.Contains(t, logMsg, config.ParseLogField(badKey, "odd"))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		entry.Debugf(ctx, "dropped %d", 1)
+	})
+	assert.LessOrEqual(t, allocs, float64(1), "a disabled level should not format the message")
+}
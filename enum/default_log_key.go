@@ -18,6 +18,7 @@ const (
 	DefaultLogKeyMessage       DefaultLogKey = "message"
 	DefaultLogKeyError         DefaultLogKey = "error"
 	DefaultLogKeyCaller        DefaultLogKey = "caller"
+	DefaultLogKeyStack         DefaultLogKey = "stack"
 	DefaultLogKeyContext       DefaultLogKey = "context"
 	DefaultLogKeyDuration      DefaultLogKey = "duration"
 	DefaultLogKeyFields        DefaultLogKey = "fields"
@@ -42,6 +43,7 @@ const (
 	DefaultLogKeyResponseTime  DefaultLogKey = "response_time"
 	DefaultLogKeyClientIP      DefaultLogKey = "client_ip"
 	DefaultLogKeyServerIP      DefaultLogKey = "server_ip"
+	DefaultLogKeyTarget        DefaultLogKey = "target"
 	DefaultLogKeyProtocol      DefaultLogKey = "protocol"
 	DefaultLogKeyMethod        DefaultLogKey = "method"
 	DefaultLogKeyURL           DefaultLogKey = "url"
@@ -0,0 +1,30 @@
+package enum
+
+// KeyCollisionPolicy decides what happens to a user, context or static field whose key
+// is already used by a field the logger writes (time, level, message, error, caller)
+// or by an earlier field of the same entry.
+type KeyCollisionPolicy string
+
+const (
+	// KeyCollisionPrefix prefixes the key with the default prefix, e.g. "custom.level",
+	// a key colliding more than once also gets a counter, e.g. "custom.user_1".
+	KeyCollisionPrefix KeyCollisionPolicy = "prefix"
+	// KeyCollisionSuffix adds a counter to the key, e.g. "level_1", "user_1", "user_2".
+	KeyCollisionSuffix KeyCollisionPolicy = "suffix"
+	// KeyCollisionLastWins keeps only the last field with the key, the fields written by the logger always win.
+	KeyCollisionLastWins KeyCollisionPolicy = "last_wins"
+	// KeyCollisionFirstWins keeps only the first field with the key, the fields written by the logger always win.
+	KeyCollisionFirstWins KeyCollisionPolicy = "first_wins"
+	// KeyCollisionNest moves the colliding fields under the fields key, e.g. "fields": {"level": "x"}.
+	KeyCollisionNest KeyCollisionPolicy = "nest"
+)
+
+// Valid reports whether p is one of the known policies.
+func (p KeyCollisionPolicy) Valid() bool {
+	switch p {
+	case KeyCollisionPrefix, KeyCollisionSuffix, KeyCollisionLastWins, KeyCollisionFirstWins, KeyCollisionNest:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,49 +1,173 @@
 package enum
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 )
 
+// LogLevel is the severity of an entry, a higher value is more severe.
+// The named levels are spaced so custom levels can be registered between them.
 type LogLevel int
 
 const (
-	LevelUnSet LogLevel = 1 << iota
-	LevelDebug LogLevel = 1 << iota
-	LevelInfo  LogLevel = 1 << iota
-	LevelWarn  LogLevel = 1 << iota
-	LevelError LogLevel = 1 << iota
-	LevelFatal LogLevel = 1 << iota
+	LevelUnSet    LogLevel = 0
+	LevelTrace    LogLevel = 10
+	LevelDebug    LogLevel = 20
+	LevelInfo     LogLevel = 30
+	LevelNotice   LogLevel = 35
+	LevelWarn     LogLevel = 40
+	LevelError    LogLevel = 50
+	LevelCritical LogLevel = 55
+	LevelFatal    LogLevel = 60
 )
 
+var (
+	ErrUnknownLevel   = errors.New("unknown log level")
+	ErrInvalidLevel   = errors.New("invalid log level name")
+	ErrDuplicateLevel = errors.New("log level already registered")
+)
+
+var builtinLevelNames = map[LogLevel]string{
+	LevelUnSet:    "UNSET",
+	LevelTrace:    "TRACE",
+	LevelDebug:    "DEBUG",
+	LevelInfo:     "INFO",
+	LevelNotice:   "NOTICE",
+	LevelWarn:     "WARN",
+	LevelError:    "ERROR",
+	LevelCritical: "CRITICAL",
+	LevelFatal:    "FATAL",
+}
+
+// levelRegistry holds the named levels, builtin and custom, sorted by severity.
+// It is replaced as a whole on every registration so readers never see a partial update.
+type levelRegistry struct {
+	names  map[LogLevel]string
+	levels map[string]LogLevel
+	sorted []LogLevel // named levels except LevelUnSet, in ascending order
+}
+
+var (
+	levelsMu sync.RWMutex
+	levels   = newLevelRegistry(builtinLevelNames)
+)
+
+func newLevelRegistry(names map[LogLevel]string) *levelRegistry {
+	r := &levelRegistry{
+		names:  names,
+		levels: make(map[string]LogLevel, len(names)),
+		sorted: make([]LogLevel, 0, len(names)),
+	}
+	for level, name := range names {
+		r.levels[name] = level
+		if level != LevelUnSet {
+			r.sorted = append(r.sorted, level)
+		}
+	}
+	sort.Slice(r.sorted, func(i, j int) bool { return r.sorted[i] < r.sorted[j] })
+	return r
+}
+
+func registry() *levelRegistry {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return levels
+}
+
+// RegisterLevel adds a custom named level, its place in the ordering is given by its severity
+// (e.g. RegisterLevel("AUDIT", LevelInfo+2) is logged when the min level is Info or below).
+// The name is case insensitive and neither the name nor the severity may already be registered.
+func RegisterLevel(name string, severity LogLevel) error {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" || strings.ContainsAny(name, "+- \t") {
+		return fmt.Errorf("%w: %q", ErrInvalidLevel, name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidLevel, name)
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	if _, exists := levels.levels[name]; exists {
+		return fmt.Errorf("%w: %q", ErrDuplicateLevel, name)
+	}
+	if existing, exists := levels.names[severity]; exists {
+		return fmt.Errorf("%w: severity %d is %s", ErrDuplicateLevel, int(severity), existing)
+	}
+	names := make(map[LogLevel]string, len(levels.names)+1)
+	for level, levelName := range levels.names {
+		names[level] = levelName
+	}
+	names[severity] = name
+	levels = newLevelRegistry(names)
+	return nil
+}
+
+// ParseLevel returns the level named s, case insensitive, as written by String
+// (e.g. "info", "WARN", "INFO+2"), or a level given as its integer severity.
+func ParseLevel(s string) (LogLevel, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	if severity, err := strconv.Atoi(name); err == nil {
+		return LogLevel(severity), nil
+	}
+	offset := 0
+	if i := strings.IndexAny(name, "+-"); i > 0 {
+		n, err := strconv.Atoi(name[i:])
+		if err != nil {
+			return LevelUnSet, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+		}
+		name, offset = name[:i], n
+	}
+	level, exists := registry().levels[name]
+	if !exists {
+		return LevelUnSet, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+	}
+	return level + LogLevel(offset), nil
+}
+
 // String returns a name for the level.
 // If the level has a name, then that name
 // in uppercase is returned.
 // If the level is between named values, then
-// an integer is appended to the uppercased name.
+// an integer is appended to the uppercased name
+// of the closest named level below it.
 // Examples:
 //
 //	LevelWarn.String() => "WARN"
 //	(LevelInfo+2).String() => "INFO+2"
+//	(LevelTrace-5).String() => "TRACE-5"
 func (l LogLevel) String() string {
-	str := func(base string, val LogLevel) string {
-		if val == 0 {
-			return base
+	if name, exists := builtinLevelNames[l]; exists {
+		return name
+	}
+	r := registry()
+	if name, exists := r.names[l]; exists {
+		return name
+	}
+	base := r.sorted[0]
+	for _, level := range r.sorted {
+		if level > l {
+			break
 		}
-		return fmt.Sprintf("%s%+d", base, val)
-	}
-
-	switch l {
-	case LevelInfo:
-		return "INFO"
-	case LevelDebug:
-		return "DEBUG"
-	case LevelWarn:
-		return "WARN"
-	case LevelError:
-		return "ERROR"
-	case LevelFatal:
-		return "FATAL"
-	default:
-		return str("ERROR", l-LevelError)
+		base = level
+	}
+	return fmt.Sprintf("%s%+d", r.names[base], int(l-base))
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevel.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
 	}
+	*l = level
+	return nil
 }
@@ -0,0 +1,87 @@
+package enum
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelOrdering(t *testing.T) {
+	ordered := []LogLevel{LevelUnSet, LevelTrace, LevelDebug, LevelInfo, LevelNotice, LevelWarn, LevelError, LevelCritical, LevelFatal}
+	for i := 1; i < len(ordered); i++ {
+		assert.Less(t, ordered[i-1], ordered[i])
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	assert.Equal(t, "UNSET", LevelUnSet.String())
+	assert.Equal(t, "TRACE", LevelTrace.String())
+	assert.Equal(t, "NOTICE", LevelNotice.String())
+	assert.Equal(t, "CRITICAL", LevelCritical.String())
+	assert.Equal(t, "INFO+2", (LevelInfo + 2).String())
+	assert.Equal(t, "TRACE-5", (LevelTrace - 5).String())
+	assert.Equal(t, "FATAL+10", (LevelFatal + 10).String())
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]LogLevel{
+		"info":      LevelInfo,
+		" WARN ":    LevelWarn,
+		"Trace":     LevelTrace,
+		"critical":  LevelCritical,
+		"INFO+2":    LevelInfo + 2,
+		"error-1":   LevelError - 1,
+		"45":        LogLevel(45),
+		"unset":     LevelUnSet,
+		"TRACE-5":   LevelTrace - 5,
+		"notice+0":  LevelNotice,
+		"fatal+100": LevelFatal + 100,
+	}
+	for s, expected := range cases {
+		level, err := ParseLevel(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, level, s)
+	}
+	for _, s := range []string{"", "verbose", "INFO+x", "+"} {
+		_, err := ParseLevel(s)
+		assert.True(t, errors.Is(err, ErrUnknownLevel), s)
+	}
+}
+
+// keepLevels restores the registered levels as they were before the test once it finished.
+func keepLevels(t *testing.T) {
+	saved := registry()
+	t.Cleanup(func() {
+		levelsMu.Lock()
+		defer levelsMu.Unlock()
+		levels = saved
+	})
+}
+
+func TestRegisterLevel(t *testing.T) {
+	keepLevels(t)
+	assert.NoError(t, RegisterLevel("audit", LevelInfo+2))
+	assert.Equal(t, "AUDIT", (LevelInfo + 2).String())
+	assert.Equal(t, "AUDIT+1", (LevelInfo + 3).String())
+	level, err := ParseLevel("Audit")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelInfo+2, level)
+
+	assert.ErrorIs(t, RegisterLevel("AUDIT", LevelInfo+4), ErrDuplicateLevel)
+	assert.ErrorIs(t, RegisterLevel("other", LevelWarn), ErrDuplicateLevel)
+	assert.ErrorIs(t, RegisterLevel("bad-name", LevelWarn+1), ErrInvalidLevel)
+	assert.ErrorIs(t, RegisterLevel("12", LevelWarn+1), ErrInvalidLevel)
+}
+
+func TestLevelTextMarshaling(t *testing.T) {
+	data, err := json.Marshal(map[string]LogLevel{"level": LevelWarn})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"level": "WARN"}`, string(data))
+
+	var decoded struct{ Level LogLevel }
+	assert.NoError(t, json.Unmarshal([]byte(`{"Level": "critical"}`), &decoded))
+	assert.Equal(t, LevelCritical, decoded.Level)
+	assert.Error(t, json.Unmarshal([]byte(`{"Level": "loud"}`), &decoded))
+}
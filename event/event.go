@@ -0,0 +1,308 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const (
+	initialFieldsSize     = 16                                        // Initial capacity of the fields of a new event
+	initialValuesSize     = 256                                       // Initial capacity of the encoded values of a new event
+	maxRetainedFieldsSize = 256                                       // Events that grew past this many fields are left to the GC
+	timeLayout            = "2006-01-02 15:04:05.999999999 -0700 MST" // time.Time.String without the monotonic clock
+)
+
+var eventPool = sync.Pool{
+	New: func() any {
+		return &Event{
+			fields:  make([]model.LogAttr, 0, initialFieldsSize),
+			typed:   make([]typedField, 0, initialFieldsSize),
+			values:  make([]byte, 0, initialValuesSize),
+			encoded: make([]model.Encoded, 0, initialFieldsSize),
+		}
+	},
+}
+
+// Logger creates events that are logged through a LogEntry.
+type Logger struct {
+	entry *entry.LogEntry
+}
+
+// New returns a Logger that logs through e, a nil e uses a new LogEntry.
+func New(e *entry.LogEntry) *Logger {
+	if e == nil {
+		e = entry.NewLogEntry()
+	}
+	return &Logger{entry: e}
+}
+
+// Debug starts a new event at debug level.
+func (l *Logger) Debug(ctx context.Context) *Event {
+	return l.WithLevel(enum.LevelDebug, ctx)
+}
+
+// Info starts a new event at info level.
+func (l *Logger) Info(ctx context.Context) *Event {
+	return l.WithLevel(enum.LevelInfo, ctx)
+}
+
+// Warn starts a new event at warn level.
+func (l *Logger) Warn(ctx context.Context) *Event {
+	return l.WithLevel(enum.LevelWarn, ctx)
+}
+
+// Error starts a new event at error level.
+func (l *Logger) Error(ctx context.Context) *Event {
+	return l.WithLevel(enum.LevelError, ctx)
+}
+
+// WithLevel starts a new event at level, it returns nil when the level is disabled,
+// every method of a nil Event is a no-op.
+func (l *Logger) WithLevel(level enum.LogLevel, ctx context.Context) *Event {
+	if !l.entry.Enabled(level) {
+		return nil
+	}
+	e := eventPool.Get().(*Event)
+	e.entry = l.entry
+	e.level = level
+	e.ctx = ctx
+	return e
+}
+
+// Event is a single log entry being built, it is pooled and must not be used
+// after Msg, Msgf or Send is called.
+type Event struct {
+	entry   *entry.LogEntry
+	level   enum.LogLevel
+	ctx     context.Context
+	err     error
+	fields  []model.LogAttr
+	typed   []typedField    // the values of the typed setters, set in fields by Msg
+	values  []byte          // the values encoded by Msg
+	encoded []model.Encoded // the encoded values, referenced by fields
+}
+
+// valueKind is the setter a typedField was added by.
+type valueKind uint8
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindInt64
+	kindUint64
+	kindFloat64
+	kindBool
+	kindDuration
+	kindTime
+)
+
+// typedField is a value of a typed setter kept unboxed until Msg, which encodes it
+// or, when the config has a redactor or ReplaceAttr, hands it to them as a value.
+type typedField struct {
+	index int // index of the field in Event.fields
+	kind  valueKind
+	num   uint64 // the int, uint, bool, float and duration values
+	str   string
+	time  time.Time
+}
+
+func (e *Event) add(key string, value any) *Event {
+	if e == nil {
+		return e
+	}
+	e.fields = append(e.fields, model.LogAttr{Key: model.LogAttrKey(key), Value: model.LogAttrValue(value)})
+	return e
+}
+
+// addTyped adds the field key, its value is set from f by Msg.
+func (e *Event) addTyped(key string, f typedField) *Event {
+	if e == nil {
+		return e
+	}
+	f.index = len(e.fields)
+	e.typed = append(e.typed, f)
+	e.fields = append(e.fields, model.LogAttr{Key: model.LogAttrKey(key)})
+	return e
+}
+
+// setTypedFields sets the values of the typed setters in fields, encoded in values when encode
+// is set, boxed otherwise so the redactor and ReplaceAttr of the config are given the values themselves.
+func (e *Event) setTypedFields(encode bool) {
+	if encode {
+		// the fields point into encoded, it must not grow once they do
+		e.encoded = slices.Grow(e.encoded[:0], len(e.typed))
+	}
+	for _, f := range e.typed {
+		if !encode {
+			e.fields[f.index].Value = f.value()
+			continue
+		}
+		start := len(e.values)
+		e.values = f.appendValue(e.values)
+		e.encoded = append(e.encoded, model.Encoded{B: e.values[start:len(e.values):len(e.values)]})
+		e.fields[f.index].Value = &e.encoded[len(e.encoded)-1]
+	}
+}
+
+// value returns the value as the setter was given it.
+func (f typedField) value() any {
+	switch f.kind {
+	case kindInt:
+		return int(f.num)
+	case kindInt64:
+		return int64(f.num)
+	case kindUint64:
+		return f.num
+	case kindFloat64:
+		return math.Float64frombits(f.num)
+	case kindBool:
+		return f.num == 1
+	case kindDuration:
+		return time.Duration(f.num)
+	case kindTime:
+		return f.time
+	default:
+		return f.str
+	}
+}
+
+// appendValue appends the value encoded as the fields of the entry encode it.
+func (f typedField) appendValue(dst []byte) []byte {
+	switch f.kind {
+	case kindInt, kindInt64:
+		return strconv.AppendInt(dst, int64(f.num), 10)
+	case kindUint64:
+		return strconv.AppendUint(dst, f.num, 10)
+	case kindFloat64:
+		return strconv.AppendFloat(dst, math.Float64frombits(f.num), 'f', 6, 64)
+	case kindBool:
+		return strconv.AppendBool(dst, f.num == 1)
+	case kindDuration:
+		return customTime.AppendDuration(dst, time.Duration(f.num))
+	case kindTime:
+		return f.time.AppendFormat(dst, timeLayout)
+	default:
+		return config.AppendEscapedString(dst, f.str)
+	}
+}
+
+// Enabled reports whether the event will be logged.
+func (e *Event) Enabled() bool {
+	return e != nil
+}
+
+func (e *Event) Str(key, value string) *Event {
+	return e.addTyped(key, typedField{kind: kindString, str: value})
+}
+
+func (e *Event) Int(key string, value int) *Event {
+	return e.addTyped(key, typedField{kind: kindInt, num: uint64(value)})
+}
+
+func (e *Event) Int64(key string, value int64) *Event {
+	return e.addTyped(key, typedField{kind: kindInt64, num: uint64(value)})
+}
+
+func (e *Event) Uint64(key string, value uint64) *Event {
+	return e.addTyped(key, typedField{kind: kindUint64, num: value})
+}
+
+func (e *Event) Float64(key string, value float64) *Event {
+	return e.addTyped(key, typedField{kind: kindFloat64, num: math.Float64bits(value)})
+}
+
+func (e *Event) Bool(key string, value bool) *Event {
+	f := typedField{kind: kindBool}
+	if value {
+		f.num = 1
+	}
+	return e.addTyped(key, f)
+}
+
+func (e *Event) Dur(key string, value time.Duration) *Event {
+	return e.addTyped(key, typedField{kind: kindDuration, num: uint64(value)})
+}
+
+func (e *Event) Time(key string, value time.Time) *Event {
+	return e.addTyped(key, typedField{kind: kindTime, time: value})
+}
+
+func (e *Event) Any(key string, value any) *Event {
+	return e.add(key, value)
+}
+
+// Attrs adds already built attributes to the event.
+func (e *Event) Attrs(fields ...model.LogAttr) *Event {
+	if e == nil {
+		return e
+	}
+	e.fields = append(e.fields, fields...)
+	return e
+}
+
+// Err sets the error of the event, it is logged under the default error key.
+func (e *Event) Err(err error) *Event {
+	if e == nil {
+		return e
+	}
+	e.err = err
+	return e
+}
+
+// Msg logs the event with message and releases it.
+func (e *Event) Msg(message string) {
+	if e == nil {
+		return
+	}
+	// decided once the entry is logged, so a redactor or ReplaceAttr
+	// installed while the event was built is given the values themselves
+	cfg := config.GetConfig()
+	e.setTypedFields(cfg.Redactor() == nil && cfg.ReplaceAttr() == nil)
+	e.entry.Log(e.level, e.ctx, message, e.err, e.fields...)
+	e.release()
+}
+
+// Msgf logs the event with the formatted message and releases it.
+func (e *Event) Msgf(format string, args ...any) {
+	if e == nil {
+		return
+	}
+	e.Msg(fmt.Sprintf(format, args...))
+}
+
+// Send logs the event without a message and releases it.
+func (e *Event) Send() {
+	e.Msg("")
+}
+
+// release clears the event so the pool does not keep its values alive, and returns it to the pool.
+func (e *Event) release() {
+	if cap(e.fields) > maxRetainedFieldsSize || cap(e.typed) > maxRetainedFieldsSize || cap(e.encoded) > maxRetainedFieldsSize {
+		return
+	}
+	for i := range e.fields {
+		e.fields[i] = model.LogAttr{}
+	}
+	for i := range e.typed {
+		e.typed[i] = typedField{}
+	}
+	e.fields = e.fields[:0]
+	e.typed = e.typed[:0]
+	e.values = e.values[:0]
+	e.encoded = e.encoded[:0]
+	e.entry = nil
+	e.ctx = nil
+	e.err = nil
+	eventPool.Put(e)
+}
@@ -0,0 +1,230 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/lognuggettest"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPreProcessorObserver struct {
+	mu       sync.Mutex
+	logLevel []enum.LogLevel
+	logEntry []string
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logLevel = append(t.logLevel, level)
+	t.logEntry = append(t.logEntry, string(logMsg))
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+func (t *testPreProcessorObserver) waitFor(tb testing.TB, n int) ([]enum.LogLevel, []string) {
+	assert.Eventually(tb, func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return len(t.logEntry) == n
+	}, time.Second, 5*time.Millisecond)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]enum.LogLevel{}, t.logLevel...), append([]string{}, t.logEntry...)
+}
+
+func setup(level enum.LogLevel) *testPreProcessorObserver {
+	config.SetMinLevel(level)
+	config.SetContextFieldsParser(nil)
+	config.SetStaticEnvFieldsParser(nil)
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+func TestEventLogsFields(t *testing.T) {
+	observer := setup(enum.LevelDebug)
+	log := New(nil)
+
+	log.Error(context.Background()).
+		Str("user", "alice").
+		Int("count", 3).
+		Int64("id", 42).
+		Uint64("size", 7).
+		Float64("ratio", 0.5).
+		Bool("admin", true).
+		Dur("elapsed", 1500*time.Millisecond).
+		Any("tags", []string{"a", "b"}).
+		Attrs(model.LogAttr{Key: "extra", Value: "value"}).
+		Err(errors.New("not found")).
+		Msg("done")
+
+	levels, entries := observer.waitFor(t, 1)
+	assert.Equal(t, enum.LevelError, levels[0])
+	logMsg := entries[0]
+	assert.Contains(t, logMsg, config.ParseLogField("message", "done"))
+	assert.Contains(t, logMsg, config.ParseLogField("user", "alice"))
+	assert.Contains(t, logMsg, config.ParseLogField("count", 3))
+	assert.Contains(t, logMsg, config.ParseLogField("id", 42))
+	assert.Contains(t, logMsg, config.ParseLogField("size", 7))
+	assert.Contains(t, logMsg, config.ParseLogField("ratio", 0.5))
+	assert.Contains(t, logMsg, config.ParseLogField("admin", true))
+	assert.Contains(t, logMsg, config.ParseLogField("elapsed", "1.5s"))
+	assert.Contains(t, logMsg, config.ParseLogField("tags", "[a b]"))
+	assert.Contains(t, logMsg, config.ParseLogField("extra", "value"))
+	assert.Contains(t, logMsg, `"error": {"message": "not found", "type": "*errors.errorString", "stack": ["github.com/architagr/lognugget/event.TestEventLogsFields `)
+}
+
+func TestReleasedEventDoesNotLeakFields(t *testing.T) {
+	observer := setup(enum.LevelDebug)
+	log := New(nil)
+
+	log.Info(context.Background()).Str("first", "1").Msgf("first %d", 1)
+	log.Warn(context.Background()).Str("second", "2").Send()
+
+	levels, entries := observer.waitFor(t, 2)
+	assert.Equal(t, []enum.LogLevel{enum.LevelInfo, enum.LevelWarn}, levels)
+	assert.Contains(t, entries[0], config.ParseLogField("message", "first 1"))
+	assert.NotContains(t, entries[1], "first")
+	assert.Contains(t, entries[1], config.ParseLogField("second", "2"))
+}
+
+func TestDisabledLevelReturnsNoOpEvent(t *testing.T) {
+	observer := setup(enum.LevelInfo)
+	log := New(nil)
+
+	e := log.Debug(context.Background())
+	assert.Nil(t, e)
+	assert.False(t, e.Enabled())
+	e.Str("user", "alice").Int("count", 1).Err(errors.New("ignored")).Msg("dropped")
+	log.Info(context.Background()).Msg("kept")
+
+	_, entries := observer.waitFor(t, 1)
+	assert.Contains(t, entries[0], config.ParseLogField("message", "kept"))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Debug(context.Background()).Str("user", "alice").Int("count", 1000).Msg("dropped")
+	})
+	assert.Equal(t, float64(0), allocs, "a disabled event should not allocate")
+}
+
+type recordingSink struct {
+	entries []string
+}
+
+func (s *recordingSink) WriteEntry(level enum.LogLevel, entry []byte) {
+	s.entries = append(s.entries, string(entry))
+}
+
+type discardSink struct{}
+
+func (discardSink) WriteEntry(enum.LogLevel, []byte) {}
+
+func TestTypedSettersWriteTheVariadicFields(t *testing.T) {
+	setup(enum.LevelDebug)
+	at := time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.FixedZone("IST", 5*3600+1800))
+	lognuggettest.UseFakeClock(t, at)
+	sink := &recordingSink{}
+	logEntry := entry.NewLogEntry().WithSink(sink)
+
+	New(logEntry).Info(context.Background()).
+		Str("user", "al\"ice").Int("count", 3).Int64("id", -42).Uint64("size", 7).Float64("ratio", 0.5).
+		Bool("admin", true).Dur("elapsed", 1500*time.Millisecond).Time("at", at).Any("tags", []string{"a"}).
+		Msg("done")
+	logEntry.Info(context.Background(), "done",
+		model.LogAttr{Key: "user", Value: "al\"ice"}, model.LogAttr{Key: "count", Value: 3},
+		model.LogAttr{Key: "id", Value: int64(-42)}, model.LogAttr{Key: "size", Value: uint64(7)},
+		model.LogAttr{Key: "ratio", Value: 0.5}, model.LogAttr{Key: "admin", Value: true},
+		model.LogAttr{Key: "elapsed", Value: 1500 * time.Millisecond}, model.LogAttr{Key: "at", Value: at},
+		model.LogAttr{Key: "tags", Value: []string{"a"}})
+
+	if assert.Len(t, sink.entries, 2) {
+		assert.Equal(t, sink.entries[1], sink.entries[0])
+	}
+}
+
+// valueRedactor masks the string values containing secret and records the values it was given.
+type valueRedactor struct {
+	values []any
+}
+
+func (r *valueRedactor) RedactAttr(a model.LogAttr) model.LogAttr {
+	r.values = append(r.values, a.Value)
+	if s, ok := a.Value.(string); ok && strings.Contains(s, "secret") {
+		a.Value = "***"
+	}
+	return a
+}
+
+func TestRedactorInstalledWhileTheEventIsBuiltIsGivenTheValues(t *testing.T) {
+	setup(enum.LevelDebug)
+	sink := &recordingSink{}
+	redactor := &valueRedactor{}
+	t.Cleanup(func() { config.SetRedactor(nil) })
+
+	e := New(entry.NewLogEntry().WithSink(sink)).Info(context.Background()).Str("token", "secret-1").Int("count", 3)
+	config.SetRedactor(redactor)
+	e.Msg("done")
+
+	assert.Equal(t, []any{"secret-1", 3}, redactor.values)
+	if assert.Len(t, sink.entries, 1) {
+		assert.Contains(t, sink.entries[0], config.ParseLogField("token", "***"))
+		assert.Contains(t, sink.entries[0], config.ParseLogField("count", 3))
+		assert.NotContains(t, sink.entries[0], "secret-1")
+	}
+}
+
+func TestTypedSettersDoNotBoxTheirValues(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector makes sync.Pool drop the pooled events")
+	}
+	setup(enum.LevelDebug)
+	logEntry := entry.NewLogEntry().WithSink(discardSink{})
+	log := New(logEntry)
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	fluent := testing.AllocsPerRun(100, func() {
+		log.Info(context.Background()).Str("user", "alice").Int("count", 1000).Float64("ratio", 0.5).
+			Dur("elapsed", time.Second).Time("at", at).Msg("done")
+	})
+	variadic := testing.AllocsPerRun(100, func() {
+		logEntry.Info(context.Background(), "done",
+			model.LogAttr{Key: "user", Value: "alice"}, model.LogAttr{Key: "count", Value: 1000},
+			model.LogAttr{Key: "ratio", Value: 0.5}, model.LogAttr{Key: "elapsed", Value: time.Second},
+			model.LogAttr{Key: "at", Value: at})
+	})
+	assert.Equal(t, float64(0), fluent, "the typed setters encode their values in the pooled event")
+	assert.Less(t, fluent, variadic)
+}
+
+func BenchmarkTypedSetters(b *testing.B) {
+	setup(enum.LevelDebug)
+	log := New(entry.NewLogEntry().WithSink(discardSink{}))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log.Info(context.Background()).Str("user", "alice").Int("count", i).Float64("ratio", 0.5).
+			Dur("elapsed", time.Second).Msg("done")
+	}
+}
+
+func BenchmarkVariadicFields(b *testing.B) {
+	setup(enum.LevelDebug)
+	logEntry := entry.NewLogEntry().WithSink(discardSink{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logEntry.Info(context.Background(), "done",
+			model.LogAttr{Key: "user", Value: "alice"}, model.LogAttr{Key: "count", Value: i},
+			model.LogAttr{Key: "ratio", Value: 0.5}, model.LogAttr{Key: "elapsed", Value: time.Second})
+	}
+}
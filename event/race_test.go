@@ -0,0 +1,7 @@
+//go:build race
+
+package event
+
+// raceEnabled reports whether the race detector is on, it makes sync.Pool drop
+// part of the pooled events so their allocations cannot be counted.
+const raceEnabled = true
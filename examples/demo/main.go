@@ -10,8 +10,9 @@ import (
 	"time"
 
 	"github.com/architagr/lognugget/config"
-	"github.com/architagr/lognugget/entry"
 	"github.com/architagr/lognugget/enum"
+	ginMiddleware "github.com/architagr/lognugget/gin_middleware"
+	httpMiddleware "github.com/architagr/lognugget/http_middleware"
 	"github.com/architagr/lognugget/model"
 	pipelineStage "github.com/architagr/lognugget/pipeline_stage"
 	"github.com/gin-gonic/gin"
@@ -29,10 +30,10 @@ func init() {
 		}
 	})
 	config.SetContextFieldsParser(func(ctx context.Context) map[string]any {
-		requestId := ctx.Value("request_id")
+		requestId := httpMiddleware.RequestIDFromContext(ctx)
 		userId := ctx.Value("user_id")
 		m := make(map[string]any, 2)
-		if requestId != nil {
+		if requestId != "" {
 			m["request_id"] = requestId
 		}
 		if userId != nil {
@@ -68,23 +69,20 @@ func main() {
 
 	pipelineStage.EventPreProcessorObj.RegisterHook(enum.LevelUnSet, unsetPostProcessor)
 	config.InitPreProcessors(pipelineStage.EventPreProcessorObj)
-	entry.GenerateInitialPool(10_000)
 
 	engine := gin.New()
 	// obj := zerolog.New(os.Stdout).With().Timestamp().Logger().Hook(traceHook{})
 
+	engine.Use(ginMiddleware.Handler())
 	engine.GET("/v1/user", func(ctx *gin.Context) {
 		a := []model.LogAttr{
 			{Key: model.LogAttrKey("itrr"), Value: model.LogAttrValue(ctx.RemoteIP())},
 			{Key: model.LogAttrKey("time"), Value: model.LogAttrValue(time.Now())},
 		}
-		ctxObj := context.WithValue(context.WithValue(ctx.Request.Context(), "requestID", 1), "userID", "User1234")
 		// z := obj.With().Ctx(ctx).Logger()
 		// z.Debug().Fields(map[string]any{"itrr": ctx.RemoteIP(), "time": time.Now()}).Msg("debug message that has a log message from zero log")
 
-		entryObj := entry.NewLogEntry()
-
-		entryObj.Debug(ctxObj, "debug message that has a log message", a...)
+		ginMiddleware.FromGin(ctx).Debug("debug message that has a log message", a...)
 		ctx.JSON(http.StatusOK, gin.H{
 			"message": "user retrieved",
 		})
@@ -0,0 +1,143 @@
+package ginMiddleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	httpMiddleware "github.com/architagr/lognugget/http_middleware"
+	"github.com/architagr/lognugget/model"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	loggerKey       = "lognugget.logger" // Key of the request scoped logger in the gin context
+	DefaultPanicMsg = "panic recovered"
+)
+
+var defaultLogger = entry.NewLogEntry()
+
+// Logger is a request scoped logger, it logs with the request context
+// and the fields bound by the middleware.
+type Logger struct {
+	logger *entry.LogEntry
+	ctx    context.Context
+	fields []model.LogAttr
+}
+
+// NewLogger returns a request scoped logger bound to ctx and fields, it logs through
+// a LogEntry shared by the loggers of the package.
+func NewLogger(ctx context.Context, fields ...model.LogAttr) *Logger {
+	return NewLoggerWith(nil, ctx, fields...)
+}
+
+// NewLoggerWith returns a request scoped logger that logs through logger, bound to ctx and fields.
+// A nil logger is NewLogger.
+func NewLoggerWith(logger *entry.LogEntry, ctx context.Context, fields ...model.LogAttr) *Logger {
+	if logger == nil {
+		logger = defaultLogger
+	}
+	return &Logger{logger: logger, ctx: ctx, fields: fields}
+}
+
+// Context returns the request context the logger is bound to.
+func (l *Logger) Context() context.Context {
+	return l.ctx
+}
+
+// With returns a copy of the logger with fields added to the bound fields.
+func (l *Logger) With(fields ...model.LogAttr) *Logger {
+	bound := make([]model.LogAttr, 0, len(l.fields)+len(fields))
+	bound = append(append(bound, l.fields...), fields...)
+	return &Logger{logger: l.logger, ctx: l.ctx, fields: bound}
+}
+
+func (l *Logger) log(level enum.LogLevel, err error, message string, fields []model.LogAttr) {
+	if len(l.fields) > 0 {
+		fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	}
+	l.logger.Log(level, l.ctx, message, err, fields...)
+}
+
+func (l *Logger) Debug(message string, fields ...model.LogAttr) {
+	l.log(enum.LevelDebug, nil, message, fields)
+}
+
+func (l *Logger) Info(message string, fields ...model.LogAttr) {
+	l.log(enum.LevelInfo, nil, message, fields)
+}
+
+func (l *Logger) Warn(message string, fields ...model.LogAttr) {
+	l.log(enum.LevelWarn, nil, message, fields)
+}
+
+func (l *Logger) Error(err error, message string, fields ...model.LogAttr) {
+	l.log(enum.LevelError, err, message, fields)
+}
+
+// FromGin returns the logger bound to the gin context by the middleware,
+// if the middleware is not installed a logger bound to the request context is returned.
+func FromGin(c *gin.Context) *Logger {
+	if v, ok := c.Get(loggerKey); ok {
+		if l, ok := v.(*Logger); ok {
+			return l
+		}
+	}
+	if c.Request != nil {
+		return NewLogger(c.Request.Context())
+	}
+	return NewLogger(context.Background())
+}
+
+// Handler returns the gin middleware using the default config.
+func Handler() gin.HandlerFunc {
+	return New(httpMiddleware.Config{})
+}
+
+// New returns a gin middleware that binds a request scoped logger to the gin context,
+// logs one access log entry per request and recovers panics raised by the handlers.
+func New(cfg httpMiddleware.Config) gin.HandlerFunc {
+	cfg = cfg.WithDefaults()
+	return func(c *gin.Context) {
+		start := customTime.TimeNow()
+		requestID := c.GetHeader(cfg.RequestIDHeader)
+		if !httpMiddleware.ValidRequestID(requestID) {
+			requestID = cfg.GenerateRequestID()
+		}
+		c.Header(cfg.RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(httpMiddleware.ContextWithRequestID(c.Request.Context(), requestID))
+
+		keys := config.GetConfig().DefaultFields()
+		logger := NewLoggerWith(cfg.Logger, c.Request.Context(), model.LogAttr{
+			Key:   model.LogAttrKey(keys[enum.DefaultLogKeyRequestID]),
+			Value: model.LogAttrValue(requestID),
+		})
+		c.Set(loggerKey, logger)
+
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					// aborts the response on purpose, net/http does not log it either
+					panic(r)
+				}
+				logger.Error(entry.NewPanicError(r), DefaultPanicMsg)
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+			if slices.Contains(cfg.SkipPaths, c.Request.URL.Path) {
+				return
+			}
+			status := c.Writer.Status()
+			size := c.Writer.Size()
+			if size < 0 {
+				size = 0
+			}
+			cfg.Logger.Log(cfg.LevelForStatus(status), c.Request.Context(), cfg.Message, nil,
+				httpMiddleware.AccessLogFields(c.Request, requestID, status, size, customTime.Since(start))...)
+		}()
+		c.Next()
+	}
+}
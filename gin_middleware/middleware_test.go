@@ -0,0 +1,134 @@
+package ginMiddleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	httpMiddleware "github.com/architagr/lognugget/http_middleware"
+	"github.com/architagr/lognugget/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPreProcessorObserver struct {
+	mu       sync.Mutex
+	logLevel []enum.LogLevel
+	logEntry []string
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logLevel = append(t.logLevel, level)
+	t.logEntry = append(t.logEntry, string(logMsg))
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+func (t *testPreProcessorObserver) entries() ([]enum.LogLevel, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]enum.LogLevel{}, t.logLevel...), append([]string{}, t.logEntry...)
+}
+
+func (t *testPreProcessorObserver) waitFor(tb testing.TB, n int) ([]enum.LogLevel, []string) {
+	assert.Eventually(tb, func() bool {
+		_, entries := t.entries()
+		return len(entries) == n
+	}, time.Second, 10*time.Millisecond)
+	return t.entries()
+}
+
+func setup() (*testPreProcessorObserver, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	config.SetMinLevel(enum.LevelDebug)
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	engine := gin.New()
+	engine.Use(Handler())
+	return observer, engine
+}
+
+func TestMiddlewareBindsLogger(t *testing.T) {
+	observer, engine := setup()
+	engine.GET("/v1/user", func(c *gin.Context) {
+		FromGin(c).Info("user retrieved", model.LogAttr{Key: "user", Value: "User1234"})
+		assert.Equal(t, "req-123", httpMiddleware.RequestIDFromContext(FromGin(c).Context()))
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user", nil)
+	req.Header.Set(httpMiddleware.DefaultRequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-123", rec.Header().Get(httpMiddleware.DefaultRequestIDHeader))
+	levels, entries := observer.waitFor(t, 2)
+	assert.Equal(t, []enum.LogLevel{enum.LevelInfo, enum.LevelInfo}, levels)
+	assert.Contains(t, entries[0], config.ParseLogField("message", "user retrieved"))
+	assert.Contains(t, entries[0], config.ParseLogField("user", "User1234"))
+	assert.Contains(t, entries[0], config.ParseLogField(string(enum.DefaultLogKeyRequestID), "req-123"))
+	assert.Contains(t, entries[1], config.ParseLogField(string(enum.DefaultLogKeyRequestID), "req-123"))
+	assert.Contains(t, entries[1], config.ParseLogField(string(enum.DefaultLogKeyMethod), http.MethodGet))
+	assert.Contains(t, entries[1], config.ParseLogField(string(enum.DefaultLogKeyStatusCode), 200))
+	assert.Contains(t, entries[1], config.ParseLogField(string(enum.DefaultLogKeyResponseSize), 2))
+}
+
+func TestMiddlewareRecoversPanic(t *testing.T) {
+	observer, engine := setup()
+	engine.GET("/v1/panic", func(c *gin.Context) {
+		panic(errors.New("boom"))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	levels, entries := observer.waitFor(t, 2)
+	assert.Equal(t, []enum.LogLevel{enum.LevelError, enum.LevelError}, levels)
+	assert.Contains(t, entries[0], `"error": {"message": "panic: boom"`)
+	assert.Contains(t, entries[0], `"stack": ["github.com/architagr/lognugget/gin_middleware.TestMiddlewareRecoversPanic.func1 `, "the stack starts at the panic")
+	assert.Equal(t, 1, strings.Count(entries[0], `"stack"`), "the panic is logged with a single stack")
+	assert.Contains(t, entries[1], config.ParseLogField(string(enum.DefaultLogKeyStatusCode), 500))
+}
+
+func TestMiddlewareRePanicsErrAbortHandler(t *testing.T) {
+	observer, engine := setup()
+	engine.GET("/v1/abort", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/abort", nil))
+	})
+	config.Flush()
+	_, entries := observer.waitFor(t, 0)
+	assert.Empty(t, entries)
+}
+
+func TestFromGinWithoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	logger := FromGin(c)
+	assert.NotNil(t, logger)
+	assert.Equal(t, c.Request.Context(), logger.Context())
+}
+
+func TestLoggerWithDoesNotShareFields(t *testing.T) {
+	parent := NewLogger(nil, model.LogAttr{Key: "a", Value: 1})
+	child1 := parent.With(model.LogAttr{Key: "b", Value: 2})
+	child2 := parent.With(model.LogAttr{Key: "c", Value: 3})
+	assert.Len(t, parent.fields, 1)
+	assert.Equal(t, model.LogAttrKey("b"), child1.fields[1].Key)
+	assert.Equal(t, model.LogAttrKey("c"), child2.fields[1].Key)
+}
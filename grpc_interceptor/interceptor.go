@@ -0,0 +1,281 @@
+package grpcInterceptor
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	httpMiddleware "github.com/architagr/lognugget/http_middleware"
+	"github.com/architagr/lognugget/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultRequestIDKey = "x-request-id" // Metadata key used to propagate the request id
+	DefaultTraceIDKey   = "x-trace-id"   // Metadata key used to propagate the trace id
+	DefaultServerMsg    = "grpc request completed"
+	DefaultClientMsg    = "grpc call completed"
+	protocol            = "grpc"
+)
+
+type traceIDKey struct{}
+
+type LevelForCodeFunc = func(code codes.Code) enum.LogLevel
+
+// Config controls the behaviour of the interceptors.
+// The zero value is usable, every unset field falls back to a default.
+type Config struct {
+	Logger            *entry.LogEntry                   // Logger used for the rpc logs, defaults to a new entry
+	RequestIDKey      string                            // Metadata key of the request id, defaults to x-request-id
+	TraceIDKey        string                            // Metadata key of the trace id, defaults to x-trace-id
+	GenerateRequestID httpMiddleware.RequestIDGenerator // Generates a request id on the server when none or an invalid one is received
+	LevelForCode      LevelForCodeFunc                  // Chooses the log level from the status code
+	MethodLevels      map[string]enum.LogLevel          // Per full method level, used in place of LevelForCode
+	SkipMethods       []string                          // Full method names that are not logged
+	Message           string                            // Message for the log entry
+}
+
+// withDefaults returns a copy of the config with every unset field set to its default.
+func (cfg Config) withDefaults(message string) Config {
+	if cfg.Logger == nil {
+		cfg.Logger = entry.NewLogEntry()
+	}
+	if cfg.RequestIDKey == "" {
+		cfg.RequestIDKey = DefaultRequestIDKey
+	}
+	if cfg.TraceIDKey == "" {
+		cfg.TraceIDKey = DefaultTraceIDKey
+	}
+	if cfg.GenerateRequestID == nil {
+		cfg.GenerateRequestID = httpMiddleware.NewRequestID
+	}
+	if cfg.LevelForCode == nil {
+		cfg.LevelForCode = DefaultLevelForCode
+	}
+	if cfg.Message == "" {
+		cfg.Message = message
+	}
+	return cfg
+}
+
+// TraceIDFromContext returns the trace id bound by the interceptors.
+func TraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+// ContextWithTraceID binds the trace id to the context.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// DefaultLevelForCode logs OK as info, errors caused by the caller as warn
+// and everything else as error.
+func DefaultLevelForCode(code codes.Code) enum.LogLevel {
+	switch code {
+	case codes.OK:
+		return enum.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition, codes.OutOfRange:
+		return enum.LevelWarn
+	default:
+		return enum.LevelError
+	}
+}
+
+func (cfg Config) level(method string, code codes.Code) enum.LogLevel {
+	if level, ok := cfg.MethodLevels[method]; ok {
+		return level
+	}
+	return cfg.LevelForCode(code)
+}
+
+// serverContext binds the request and trace id received in the incoming metadata to ctx,
+// generating a request id when the caller did not send a valid one.
+func (cfg Config) serverContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := firstValue(md, cfg.RequestIDKey)
+	if !httpMiddleware.ValidRequestID(requestID) {
+		requestID = cfg.GenerateRequestID()
+	}
+	ctx = httpMiddleware.ContextWithRequestID(ctx, requestID)
+	if traceID := firstValue(md, cfg.TraceIDKey); traceID != "" {
+		ctx = ContextWithTraceID(ctx, traceID)
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(cfg.RequestIDKey, requestID))
+	return ctx
+}
+
+// clientContext appends the request and trace id bound to ctx to the outgoing metadata.
+func (cfg Config) clientContext(ctx context.Context) context.Context {
+	kv := make([]string, 0, 4)
+	if requestID := httpMiddleware.RequestIDFromContext(ctx); requestID != "" {
+		kv = append(kv, cfg.RequestIDKey, requestID)
+	}
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		kv = append(kv, cfg.TraceIDKey, traceID)
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// call holds what is logged for a single rpc.
+type call struct {
+	method       string
+	peerKey      enum.DefaultLogKey
+	peer         string
+	err          error
+	duration     time.Duration
+	requestSize  int
+	responseSize int
+}
+
+func (cfg Config) log(ctx context.Context, c call) {
+	if slices.Contains(cfg.SkipMethods, c.method) {
+		return
+	}
+	code := status.Code(c.err)
+	keys := config.GetConfig().DefaultFields()
+	attr := func(key enum.DefaultLogKey, value any) model.LogAttr {
+		return model.LogAttr{Key: model.LogAttrKey(keys[key]), Value: model.LogAttrValue(value)}
+	}
+	fields := []model.LogAttr{
+		attr(enum.DefaultLogKeyMethod, c.method),
+		attr(enum.DefaultLogKeyProtocol, protocol),
+		attr(enum.DefaultLogKeyStatusCode, code.String()),
+		attr(enum.DefaultLogKeyDuration, c.duration),
+		attr(enum.DefaultLogKeyRequestSize, c.requestSize),
+		attr(enum.DefaultLogKeyResponseSize, c.responseSize),
+	}
+	if c.peer != "" {
+		fields = append(fields, attr(c.peerKey, c.peer))
+	}
+	if requestID := httpMiddleware.RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, attr(enum.DefaultLogKeyRequestID, requestID))
+	}
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		fields = append(fields, attr(enum.DefaultLogKeyTraceID, traceID))
+	}
+	cfg.Logger.Log(cfg.level(c.method, code), ctx, cfg.Message, c.err, fields...)
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func messageSize(m any) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return 0
+}
+
+// UnaryServerInterceptor logs every unary rpc handled by the server.
+func UnaryServerInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	cfg = cfg.withDefaults(DefaultServerMsg)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := customTime.TimeNow()
+		ctx = cfg.serverContext(ctx)
+		resp, err := handler(ctx, req)
+		cfg.log(ctx, call{
+			method:       info.FullMethod,
+			peerKey:      enum.DefaultLogKeyClientIP,
+			peer:         peerAddr(ctx),
+			err:          err,
+			duration:     customTime.Since(start),
+			requestSize:  messageSize(req),
+			responseSize: messageSize(resp),
+		})
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs every streaming rpc handled by the server,
+// the sizes are the totals of all the messages received and sent.
+func StreamServerInterceptor(cfg Config) grpc.StreamServerInterceptor {
+	cfg = cfg.withDefaults(DefaultServerMsg)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := customTime.TimeNow()
+		stream := &serverStream{ServerStream: ss, ctx: cfg.serverContext(ss.Context())}
+		err := handler(srv, stream)
+		cfg.log(stream.ctx, call{
+			method:       info.FullMethod,
+			peerKey:      enum.DefaultLogKeyClientIP,
+			peer:         peerAddr(stream.ctx),
+			err:          err,
+			duration:     customTime.Since(start),
+			requestSize:  stream.received,
+			responseSize: stream.sent,
+		})
+		return err
+	}
+}
+
+// UnaryClientInterceptor logs every unary rpc made by the client and
+// propagates the request and trace id to the server.
+func UnaryClientInterceptor(cfg Config) grpc.UnaryClientInterceptor {
+	cfg = cfg.withDefaults(DefaultClientMsg)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := customTime.TimeNow()
+		err := invoker(cfg.clientContext(ctx), method, req, reply, cc, opts...)
+		responseSize := 0
+		if err == nil {
+			responseSize = messageSize(reply)
+		}
+		cfg.log(ctx, call{
+			method:       method,
+			peerKey:      enum.DefaultLogKeyTarget,
+			peer:         cc.Target(),
+			err:          err,
+			duration:     customTime.Since(start),
+			requestSize:  messageSize(req),
+			responseSize: responseSize,
+		})
+		return err
+	}
+}
+
+// StreamClientInterceptor logs every streaming rpc made by the client once the
+// stream ends, or once its context is done for a stream that is not read to the
+// end, and propagates the request and trace id to the server.
+func StreamClientInterceptor(cfg Config) grpc.StreamClientInterceptor {
+	cfg = cfg.withDefaults(DefaultClientMsg)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := customTime.TimeNow()
+		cs, err := streamer(cfg.clientContext(ctx), desc, cc, method, opts...)
+		c := call{method: method, peerKey: enum.DefaultLogKeyTarget, peer: cc.Target()}
+		if err != nil {
+			c.err = err
+			c.duration = customTime.Since(start)
+			cfg.log(ctx, c)
+			return nil, err
+		}
+		stream := &clientStream{ClientStream: cs, desc: desc, cfg: cfg, ctx: ctx, start: start, call: c, done: make(chan struct{})}
+		go stream.finishOnDone()
+		return stream, nil
+	}
+}
@@ -0,0 +1,257 @@
+package grpcInterceptor
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	httpMiddleware "github.com/architagr/lognugget/http_middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	checkMethod   = "/grpc.health.v1.Health/Check"
+	watchMethod   = "/grpc.health.v1.Health/Watch"
+	collectMethod = "/lognugget.test.Collector/Collect"
+)
+
+// collectorDesc is a client streaming service answering the health requests it
+// receives with a single response once the client closed the stream.
+var collectorDesc = grpc.ServiceDesc{
+	ServiceName: "lognugget.test.Collector",
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Collect",
+		ClientStreams: true,
+		Handler: func(_ any, stream grpc.ServerStream) error {
+			for {
+				err := stream.RecvMsg(&healthpb.HealthCheckRequest{})
+				if err == io.EOF {
+					return stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+				}
+				if err != nil {
+					return err
+				}
+			}
+		},
+	}},
+}
+
+type logLine struct {
+	level enum.LogLevel
+	msg   string
+}
+
+type testPreProcessorObserver struct {
+	mu    sync.Mutex
+	lines []logLine
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, logLine{level: level, msg: string(logMsg)})
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+// waitFor returns the first line that contains message once it is logged.
+func (t *testPreProcessorObserver) waitFor(tb testing.TB, message string) logLine {
+	var found logLine
+	assert.Eventually(tb, func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for _, l := range t.lines {
+			if strings.Contains(l.msg, config.ParseLogField("message", message)) {
+				found = l
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	return found
+}
+
+func startServer(t *testing.T, cfg Config) healthpb.HealthClient {
+	return healthpb.NewHealthClient(startConn(t, cfg))
+}
+
+// startConn serves the health and collector services with the server interceptors
+// and returns a connection to them using the client interceptors.
+func startConn(t *testing.T, cfg Config) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)),
+		grpc.StreamInterceptor(StreamServerInterceptor(cfg)),
+	)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("lognugget", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	server.RegisterService(&collectorDesc, struct{}{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(Config{})),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(Config{})),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func setup() *testPreProcessorObserver {
+	config.SetMinLevel(enum.LevelDebug)
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+func TestUnaryInterceptorsPropagateIDs(t *testing.T) {
+	observer := setup()
+	client := startServer(t, Config{})
+
+	ctx := ContextWithTraceID(httpMiddleware.ContextWithRequestID(context.Background(), "req-123"), "trace-456")
+	var header metadata.MD
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "lognugget"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-123"}, header.Get(DefaultRequestIDKey))
+
+	server := observer.waitFor(t, DefaultServerMsg)
+	assert.Equal(t, enum.LevelInfo, server.level)
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyMethod), checkMethod))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyStatusCode), codes.OK.String()))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyRequestID), "req-123"))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyTraceID), "trace-456"))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyRequestSize), 11))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyResponseSize), 2))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyClientIP), "bufconn"))
+	assert.Contains(t, server.msg, string(enum.DefaultLogKeyDuration))
+
+	client1 := observer.waitFor(t, DefaultClientMsg)
+	assert.Equal(t, enum.LevelInfo, client1.level)
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyMethod), checkMethod))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyTarget), "passthrough:///bufnet"))
+	assert.NotContains(t, client1.msg, string(enum.DefaultLogKeyServerIP))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyRequestID), "req-123"))
+}
+
+func TestUnaryServerInterceptorLevelByCode(t *testing.T) {
+	observer := setup()
+	client := startServer(t, Config{Message: "server call"})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	server := observer.waitFor(t, "server call")
+	assert.Equal(t, enum.LevelWarn, server.level)
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyStatusCode), codes.NotFound.String()))
+	assert.Contains(t, server.msg, string(enum.DefaultLogKeyRequestID))
+	assert.Contains(t, server.msg, string(enum.DefaultLogKeyError))
+}
+
+func TestUnaryServerInterceptorMethodLevel(t *testing.T) {
+	observer := setup()
+	client := startServer(t, Config{
+		Message:      "server call",
+		MethodLevels: map[string]enum.LogLevel{checkMethod: enum.LevelDebug},
+	})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "lognugget"})
+	require.NoError(t, err)
+	assert.Equal(t, enum.LevelDebug, observer.waitFor(t, "server call").level)
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	observer := setup()
+	client := startServer(t, Config{Message: "server stream"})
+
+	ctx, cancel := context.WithCancel(httpMiddleware.ContextWithRequestID(context.Background(), "req-789"))
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "lognugget"})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	server := observer.waitFor(t, "server stream")
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyMethod), watchMethod))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyRequestID), "req-789"))
+	assert.Contains(t, server.msg, config.ParseLogField(string(enum.DefaultLogKeyRequestSize), 11))
+
+	client1 := observer.waitFor(t, DefaultClientMsg)
+	assert.Equal(t, enum.LevelWarn, client1.level)
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyMethod), watchMethod))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyStatusCode), codes.Canceled.String()))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyResponseSize), 2))
+}
+
+func TestStreamClientInterceptorLogsAClientStreamingCall(t *testing.T) {
+	observer := setup()
+	conn := startConn(t, Config{Message: "server stream"})
+
+	// the context is never done, the call ends with the response
+	stream, err := conn.NewStream(context.Background(), &collectorDesc.Streams[0], collectMethod)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, stream.SendMsg(&healthpb.HealthCheckRequest{Service: "lognugget"}))
+	}
+	require.NoError(t, stream.CloseSend())
+	resp := &healthpb.HealthCheckResponse{}
+	require.NoError(t, stream.RecvMsg(resp))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	client1 := observer.waitFor(t, DefaultClientMsg)
+	assert.Equal(t, enum.LevelInfo, client1.level)
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyMethod), collectMethod))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyStatusCode), codes.OK.String()))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyRequestSize), 22))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyResponseSize), 2))
+}
+
+func TestStreamClientInterceptorLogsAnAbandonedStream(t *testing.T) {
+	observer := setup()
+	client := startServer(t, Config{Message: "server stream"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "lognugget"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	cancel() // the stream is not read again
+
+	client1 := observer.waitFor(t, DefaultClientMsg)
+	assert.Equal(t, enum.LevelWarn, client1.level)
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyStatusCode), codes.Canceled.String()))
+	assert.Contains(t, client1.msg, config.ParseLogField(string(enum.DefaultLogKeyResponseSize), 2))
+}
+
+func TestDefaultLevelForCode(t *testing.T) {
+	assert.Equal(t, enum.LevelInfo, DefaultLevelForCode(codes.OK))
+	assert.Equal(t, enum.LevelWarn, DefaultLevelForCode(codes.InvalidArgument))
+	assert.Equal(t, enum.LevelError, DefaultLevelForCode(codes.Internal))
+	assert.Equal(t, enum.LevelError, DefaultLevelForCode(codes.Unavailable))
+}
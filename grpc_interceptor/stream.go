@@ -0,0 +1,107 @@
+package grpcInterceptor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	customTime "github.com/architagr/lognugget/custom_time"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// serverStream replaces the stream context with the one carrying the
+// request and trace id, and counts the size of the messages.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received int
+	sent     int
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received += messageSize(m)
+	}
+	return err
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent += messageSize(m)
+	}
+	return err
+}
+
+// clientStream counts the size of the messages and logs the call once the server
+// ends the stream, the response of a client streaming call is received or the
+// context of the stream is done.
+type clientStream struct {
+	grpc.ClientStream
+	desc  *grpc.StreamDesc
+	cfg   Config
+	ctx   context.Context
+	start time.Time
+	once  sync.Once
+	done  chan struct{} // closed once the call is logged
+	mu    sync.Mutex
+	call  call
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.mu.Lock()
+		s.call.requestSize += messageSize(m)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.mu.Lock()
+		s.call.responseSize += messageSize(m)
+		s.mu.Unlock()
+		if !s.desc.ServerStreams {
+			// the single response of a client streaming call, e.g. of CloseAndRecv, ends it
+			s.finish(nil)
+		}
+		return nil
+	}
+	s.finish(err)
+	return err
+}
+
+// finishOnDone logs the call when the context of the stream is done first, e.g. a stream
+// the client abandoned by canceling it, as grpc ends such a stream without RecvMsg failing.
+func (s *clientStream) finishOnDone() {
+	select {
+	case <-s.ctx.Done():
+		s.finish(status.FromContextError(s.ctx.Err()).Err())
+	case <-s.done:
+	}
+}
+
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		defer close(s.done)
+		s.mu.Lock()
+		c := s.call
+		s.mu.Unlock()
+		if !errors.Is(err, io.EOF) {
+			c.err = err
+		}
+		c.duration = customTime.Since(s.start)
+		s.cfg.log(s.ctx, c)
+	})
+}
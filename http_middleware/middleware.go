@@ -0,0 +1,231 @@
+package httpMiddleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const (
+	DefaultRequestIDHeader = "X-Request-ID" // Header used to read and write the request id
+	DefaultAccessLogMsg    = "request completed"
+	MaxRequestIDLength     = 128 // Longest request id accepted from a client
+)
+
+type requestIDKey struct{}
+
+type LevelForStatusFunc = func(status int) enum.LogLevel
+type RequestIDGenerator = func() string
+
+// Config controls the behaviour of the access log middleware.
+// The zero value is usable, every unset field falls back to a default.
+type Config struct {
+	Logger            *entry.LogEntry    // Logger used for the access log, defaults to a new entry
+	RequestIDHeader   string             // Header to read/write the request id, defaults to X-Request-ID
+	GenerateRequestID RequestIDGenerator // Generates a request id when the header is missing or invalid
+	LevelForStatus    LevelForStatusFunc // Chooses the log level from the response status
+	SkipPaths         []string           // Request paths that are served without an access log
+	Message           string             // Message for the access log entry
+}
+
+// RequestIDFromContext returns the request id bound by the middleware,
+// it can be used inside a ContextFieldsParser to add the id to every log.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithRequestID binds the request id to the context.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// DefaultLevelForStatus logs 5xx as error, 4xx as warn and everything else as info.
+func DefaultLevelForStatus(status int) enum.LogLevel {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return enum.LevelError
+	case status >= http.StatusBadRequest:
+		return enum.LevelWarn
+	default:
+		return enum.LevelInfo
+	}
+}
+
+// NewRequestID returns a random 16 byte hex encoded id.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID reports whether a request id received from a client can be used as is,
+// it must be non empty, at most MaxRequestIDLength bytes long and printable ASCII.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler wraps next with the access log middleware using the default config.
+func Handler(next http.Handler) http.Handler {
+	return New(Config{})(next)
+}
+
+// WithDefaults returns a copy of the config with every unset field set to its default.
+func (cfg Config) WithDefaults() Config {
+	if cfg.Logger == nil {
+		cfg.Logger = entry.NewLogEntry()
+	}
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if cfg.GenerateRequestID == nil {
+		cfg.GenerateRequestID = NewRequestID
+	}
+	if cfg.LevelForStatus == nil {
+		cfg.LevelForStatus = DefaultLevelForStatus
+	}
+	if cfg.Message == "" {
+		cfg.Message = DefaultAccessLogMsg
+	}
+	return cfg
+}
+
+// New returns a middleware that propagates the request id and logs one entry per request.
+func New(cfg Config) func(http.Handler) http.Handler {
+	cfg = cfg.WithDefaults()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := customTime.TimeNow()
+			requestID := r.Header.Get(cfg.RequestIDHeader)
+			if !ValidRequestID(requestID) {
+				requestID = cfg.GenerateRequestID()
+			}
+			w.Header().Set(cfg.RequestIDHeader, requestID)
+			r = r.WithContext(ContextWithRequestID(r.Context(), requestID))
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if slices.Contains(cfg.SkipPaths, r.URL.Path) {
+				return
+			}
+			status := rw.status
+			cfg.Logger.Log(cfg.LevelForStatus(status), r.Context(), cfg.Message, nil,
+				AccessLogFields(r, requestID, status, rw.size, customTime.Since(start))...)
+		})
+	}
+}
+
+// AccessLogFields builds the standard access log attributes, keyed by the
+// configured names of the http default log keys.
+func AccessLogFields(r *http.Request, requestID string, status, responseSize int, latency time.Duration) []model.LogAttr {
+	keys := config.GetConfig().DefaultFields()
+	attr := func(key enum.DefaultLogKey, value any) model.LogAttr {
+		return model.LogAttr{Key: model.LogAttrKey(keys[key]), Value: model.LogAttrValue(value)}
+	}
+	fields := []model.LogAttr{
+		attr(enum.DefaultLogKeyRequestID, requestID),
+		attr(enum.DefaultLogKeyMethod, r.Method),
+		attr(enum.DefaultLogKeyURL, r.URL.String()),
+		attr(enum.DefaultLogKeyProtocol, r.Proto),
+		attr(enum.DefaultLogKeyStatusCode, status),
+		attr(enum.DefaultLogKeyLatency, latency),
+		attr(enum.DefaultLogKeyResponseSize, responseSize),
+		attr(enum.DefaultLogKeyClientIP, ClientIP(r)),
+		attr(enum.DefaultLogKeyUserAgent, r.UserAgent()),
+		attr(enum.DefaultLogKeyReferer, r.Referer()),
+	}
+	if r.ContentLength >= 0 {
+		fields = append(fields, attr(enum.DefaultLogKeyRequestSize, r.ContentLength))
+	}
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		fields = append(fields, attr(enum.DefaultLogKeyForwardedFor, forwardedFor))
+	}
+	return fields
+}
+
+// ClientIP returns the originating client ip, preferring the proxy headers
+// over the remote address of the connection.
+func ClientIP(r *http.Request) string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		ip, _, _ := strings.Cut(forwardedFor, ",")
+		return strings.TrimSpace(ip)
+	}
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseWriter records the status code and body size written by the handler.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher when the wrapped writer supports it.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the wrapped writer supports it, e.g. for websockets.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("%T does not implement http.Hijacker: %w", w.ResponseWriter, http.ErrNotSupported)
+}
+
+// Unwrap lets http.ResponseController reach the wrapped writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
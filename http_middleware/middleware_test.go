@@ -0,0 +1,215 @@
+package httpMiddleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPreProcessorObserver struct {
+	mu       sync.Mutex
+	logLevel []enum.LogLevel
+	logEntry []string
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logLevel = append(t.logLevel, level)
+	t.logEntry = append(t.logEntry, string(logMsg))
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+func (t *testPreProcessorObserver) entries() ([]enum.LogLevel, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]enum.LogLevel{}, t.logLevel...), append([]string{}, t.logEntry...)
+}
+
+func setup() *testPreProcessorObserver {
+	config.SetMinLevel(enum.LevelDebug)
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+func TestMiddlewarePropagatesRequestID(t *testing.T) {
+	observer := setup()
+	var ctxRequestID string
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxRequestID = RequestIDFromContext(r.Context())
+		w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user?id=1", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-123")
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Referer", "http://example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-123", ctxRequestID)
+	assert.Equal(t, "req-123", rec.Header().Get(DefaultRequestIDHeader))
+	assert.Eventually(t, func() bool {
+		_, entries := observer.entries()
+		return len(entries) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	levels, entries := observer.entries()
+	assert.Equal(t, enum.LevelInfo, levels[0])
+	logMsg := entries[0]
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyRequestID), "req-123"))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyMethod), http.MethodGet))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyURL), "/v1/user?id=1"))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyStatusCode), 200))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyResponseSize), 5))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyClientIP), "192.0.2.1"))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyUserAgent), "test-agent"))
+	assert.Contains(t, logMsg, config.ParseLogField(string(enum.DefaultLogKeyReferer), "http://example.com"))
+	assert.Contains(t, logMsg, string(enum.DefaultLogKeyLatency))
+	assert.NotContains(t, logMsg, config.DefaultPrefix)
+}
+
+func TestMiddlewareGeneratesRequestIDAndLevelByStatus(t *testing.T) {
+	observer := setup()
+	var ctxRequestID string
+	handler := New(Config{
+		GenerateRequestID: func() string { return "generated" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxRequestID = RequestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/order", strings.NewReader("{}")))
+
+	assert.Equal(t, "generated", ctxRequestID)
+	assert.Equal(t, "generated", rec.Header().Get(DefaultRequestIDHeader))
+	assert.Eventually(t, func() bool {
+		_, entries := observer.entries()
+		return len(entries) == 1
+	}, time.Second, 10*time.Millisecond)
+	levels, entries := observer.entries()
+	assert.Equal(t, enum.LevelError, levels[0])
+	assert.Contains(t, entries[0], config.ParseLogField(string(enum.DefaultLogKeyStatusCode), 500))
+	assert.Contains(t, entries[0], config.ParseLogField(string(enum.DefaultLogKeyRequestSize), 2))
+}
+
+func TestMiddlewareReplacesAnInvalidRequestID(t *testing.T) {
+	setup()
+	for name, requestID := range map[string]string{
+		"empty":      "",
+		"too long":   strings.Repeat("a", MaxRequestIDLength+1),
+		"control":    "req\x00123",
+		"non ascii":  "req-é",
+		"line break": "req\n{\"level\": \"error\"}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ctxRequestID string
+			handler := New(Config{
+				GenerateRequestID: func() string { return "generated" },
+				SkipPaths:         []string{"/"},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxRequestID = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header[DefaultRequestIDHeader] = []string{requestID}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, "generated", ctxRequestID)
+			assert.Equal(t, "generated", rec.Header().Get(DefaultRequestIDHeader))
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("req-123"))
+	assert.True(t, ValidRequestID(strings.Repeat("a", MaxRequestIDLength)))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID(strings.Repeat("a", MaxRequestIDLength+1)))
+	assert.False(t, ValidRequestID("req\t123"))
+	assert.False(t, ValidRequestID("req\x7f"))
+}
+
+func TestMiddlewareSkipPaths(t *testing.T) {
+	observer := setup()
+	handler := New(Config{SkipPaths: []string{"/healthz"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/user", nil))
+
+	assert.Eventually(t, func() bool {
+		_, entries := observer.entries()
+		return len(entries) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, entries := observer.entries()
+	assert.Contains(t, entries[0], config.ParseLogField(string(enum.DefaultLogKeyURL), "/v1/user"))
+}
+
+func TestDefaultLevelForStatus(t *testing.T) {
+	assert.Equal(t, enum.LevelInfo, DefaultLevelForStatus(http.StatusOK))
+	assert.Equal(t, enum.LevelInfo, DefaultLevelForStatus(http.StatusFound))
+	assert.Equal(t, enum.LevelWarn, DefaultLevelForStatus(http.StatusNotFound))
+	assert.Equal(t, enum.LevelError, DefaultLevelForStatus(http.StatusBadGateway))
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, "192.0.2.1", ClientIP(req))
+	req.Header.Set("X-Real-IP", "10.0.0.2")
+	assert.Equal(t, "10.0.0.2", ClientIP(req))
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.3")
+	assert.Equal(t, "10.0.0.1", ClientIP(req))
+}
+
+func TestMiddlewareKeepsTheOptionalInterfacesOfTheWriter(t *testing.T) {
+	setup()
+	served := make(chan struct{})
+	hijacking := New(Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	}))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(served)
+		hijacking.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if assert.NoError(t, err) {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		assert.Equal(t, "hijacked", string(body))
+	}
+
+	handler := New(Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		_, _, err := w.(http.Hijacker).Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, rec.Flushed)
+	<-served
+	config.Flush()
+}
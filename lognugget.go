@@ -0,0 +1,291 @@
+package lognugget
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/encoder"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	pipelineStage "github.com/architagr/lognugget/pipeline_stage"
+)
+
+var ErrInvalidOption = errors.New("invalid logger option")
+
+var (
+	setupMu sync.Mutex // serializes New and Close
+	active  *Logger    // the logger whose hooks are registered, replaced by the next New
+)
+
+// Hook receives every entry logged at the level it is registered for, entry is
+// only valid until PublishLogMessage returns and must be copied to be retained.
+type Hook interface {
+	PublishLogMessage(entry []byte)
+	Name() string
+}
+
+type levelHook struct {
+	level enum.LogLevel
+	hook  Hook
+}
+
+type options struct {
+	level         enum.LogLevel
+	encoderType   enum.LogEncodeType
+	output        io.Writer
+	bufferSize    int
+	rate          time.Duration
+	staticFields  map[string]any
+	contextParser config.ContextFieldsParser
+	hooks         []levelHook
+	sampler       entry.Sampler
+	redactor      config.AttrRedactor
+	replaceAttr   config.ReplaceAttrFunc
+	keyCollision  enum.KeyCollisionPolicy
+	sync          bool
+}
+
+// Option configures the logger created by New, an invalid value makes New return an error.
+type Option func(o *options) error
+
+// WithLevel sets the minimum level that is logged.
+func WithLevel(level enum.LogLevel) Option {
+	return func(o *options) error {
+		o.level = level
+		return nil
+	}
+}
+
+// WithEncoder sets the encoding of the entries, json or text.
+func WithEncoder(encoderType enum.LogEncodeType) Option {
+	return func(o *options) error {
+		if _, err := encoder.DefaultEncoderFactory(encoderType); err != nil {
+			return fmt.Errorf("%w: encoder %q: %v", ErrInvalidOption, encoderType, err)
+		}
+		o.encoderType = encoderType
+		return nil
+	}
+}
+
+// WithOutput sets the writer the batched entries are written to.
+func WithOutput(output io.Writer) Option {
+	return func(o *options) error {
+		if output == nil {
+			return fmt.Errorf("%w: output is nil", ErrInvalidOption)
+		}
+		o.output = output
+		return nil
+	}
+}
+
+// WithBatching sets how many entries are batched before they are written
+// and the rate at which a partial batch is written.
+func WithBatching(size int, rate time.Duration) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("%w: batch size %d must be positive", ErrInvalidOption, size)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("%w: batch rate %s must be positive", ErrInvalidOption, rate)
+		}
+		o.bufferSize, o.rate = size, rate
+		return nil
+	}
+}
+
+// WithStaticFields adds fields to every entry, e.g. the service name or host.
+func WithStaticFields(fields map[string]any) Option {
+	return func(o *options) error {
+		for key := range fields {
+			if key == "" {
+				return fmt.Errorf("%w: static field with an empty key", ErrInvalidOption)
+			}
+		}
+		o.staticFields = fields
+		return nil
+	}
+}
+
+// WithContextParser sets the function extracting fields from the context of every entry.
+func WithContextParser(parser config.ContextFieldsParser) Option {
+	return func(o *options) error {
+		if parser == nil {
+			return fmt.Errorf("%w: context parser is nil", ErrInvalidOption)
+		}
+		o.contextParser = parser
+		return nil
+	}
+}
+
+// WithHook registers hook for the entries at level, enum.LevelUnSet registers it for every level.
+func WithHook(level enum.LogLevel, hook Hook) Option {
+	return func(o *options) error {
+		if hook == nil {
+			return fmt.Errorf("%w: hook is nil", ErrInvalidOption)
+		}
+		o.hooks = append(o.hooks, levelHook{level: level, hook: hook})
+		return nil
+	}
+}
+
+// WithSampler sets the sampler deciding which enabled entries are logged, see entry.EveryN.
+func WithSampler(sampler entry.Sampler) Option {
+	return func(o *options) error {
+		if sampler == nil {
+			return fmt.Errorf("%w: sampler is nil", ErrInvalidOption)
+		}
+		o.sampler = sampler
+		return nil
+	}
+}
+
+// WithRedactor sets the redactor masking sensitive attributes before they are encoded, see redaction.New.
+func WithRedactor(redactor config.AttrRedactor) Option {
+	return func(o *options) error {
+		if redactor == nil {
+			return fmt.Errorf("%w: redactor is nil", ErrInvalidOption)
+		}
+		o.redactor = redactor
+		return nil
+	}
+}
+
+// WithReplaceAttr sets the function rewriting every attribute before it is encoded,
+// including the default time, level and message fields, see config.SetReplaceAttr.
+func WithReplaceAttr(replace config.ReplaceAttrFunc) Option {
+	return func(o *options) error {
+		if replace == nil {
+			return fmt.Errorf("%w: replace attr is nil", ErrInvalidOption)
+		}
+		o.replaceAttr = replace
+		return nil
+	}
+}
+
+// WithKeyCollisionPolicy sets what happens to the fields whose key is already used
+// by the logger or an earlier field of the entry, see enum.KeyCollisionPolicy.
+func WithKeyCollisionPolicy(policy enum.KeyCollisionPolicy) Option {
+	return func(o *options) error {
+		if !policy.Valid() {
+			return fmt.Errorf("%w: key collision policy %q", ErrInvalidOption, policy)
+		}
+		o.keyCollision = policy
+		return nil
+	}
+}
+
+// WithSync makes the logger write every entry on the calling goroutine before the
+// logging call returns, with the same bytes as the batched writes. WithBatching is ignored.
+func WithSync() Option {
+	return func(o *options) error {
+		o.sync = true
+		return nil
+	}
+}
+
+// Logger is a LogEntry bound to the pipeline set up by New.
+type Logger struct {
+	*entry.LogEntry
+	postProcessor interface{ Stop() }
+	hooks         []levelHook // registered by New, the post processor included
+	closeOnce     sync.Once
+}
+
+// New validates opts, applies them to the logger config and sets up the pipeline
+// writing the entries in batches, or synchronously with WithSync, to the output. Nothing is changed when an option is invalid.
+// The config and pipeline are global, a later New replaces the setup of an earlier one:
+// the hooks of the earlier logger are removed and its batched entries are written.
+func New(opts ...Option) (*Logger, error) {
+	o := options{
+		level:        config.DafaultLevel,
+		encoderType:  config.DafaultEncoderType,
+		output:       config.DefaultOutput,
+		bufferSize:   config.DafaultLogBuffer,
+		rate:         time.Second,
+		keyCollision: config.DefaultKeyCollision,
+	}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	setupMu.Lock()
+	defer setupMu.Unlock()
+	previous := active
+
+	config.ApplySetup(config.Setup{
+		Level:         o.level,
+		EncoderType:   o.encoderType,
+		Output:        o.output,
+		LogBuffer:     o.bufferSize,
+		Rate:          o.rate,
+		StaticFields:  o.staticFields,
+		ContextParser: o.contextParser,
+		Redactor:      o.redactor,
+		ReplaceAttr:   o.replaceAttr,
+		KeyCollision:  o.keyCollision,
+	})
+
+	var postProcessor interface {
+		Hook
+		Stop()
+	}
+	if o.sync {
+		postProcessor = pipelineStage.NewSyncLogEventPostProcessor(o.output)
+	} else {
+		postProcessor = pipelineStage.NewUnsetLogEventPostProcessor(o.rate, o.bufferSize, o.output)
+	}
+	l := &Logger{
+		LogEntry:      entry.NewLogEntry().WithSampler(o.sampler).WithSync(o.sync),
+		postProcessor: postProcessor,
+		hooks:         append([]levelHook{{level: enum.LevelUnSet, hook: postProcessor}}, o.hooks...),
+	}
+	for _, h := range l.hooks {
+		pipelineStage.EventPreProcessorObj.RegisterHook(h.level, h.hook)
+	}
+	config.InitPreProcessors(pipelineStage.EventPreProcessorObj)
+	if previous != nil {
+		// the hooks l registered under the same name already replaced those of previous
+		previous.deregisterHooks(l.hooks)
+		// the entries already published may still be on their way to the previous post processor
+		config.Flush()
+		previous.stop()
+	}
+	active = l
+	return l, nil
+}
+
+// Close writes the entries logged so far to the output, removes the hooks of the logger unless
+// a later New replaced them and stops its batching, it returns once every entry is written.
+// Calling Close again does nothing.
+func (l *Logger) Close() {
+	setupMu.Lock()
+	defer setupMu.Unlock()
+	if active == l {
+		// the entries still in the pipeline reach the hooks before they are removed
+		config.Flush()
+		l.deregisterHooks(nil)
+		active = nil
+	}
+	l.stop()
+}
+
+// deregisterHooks removes the hooks registered by New but those registered again under the
+// same level and name in replaced, setupMu is held.
+func (l *Logger) deregisterHooks(replaced []levelHook) {
+	for _, h := range l.hooks {
+		if !slices.ContainsFunc(replaced, func(r levelHook) bool { return r.level == h.level && r.hook.Name() == h.hook.Name() }) {
+			pipelineStage.EventPreProcessorObj.DeRegisterHook(h.level, h.hook.Name())
+		}
+	}
+}
+
+func (l *Logger) stop() {
+	l.closeOnce.Do(l.postProcessor.Stop)
+}
@@ -0,0 +1,231 @@
+package lognugget
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type testHook struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (h *testHook) PublishLogMessage(entry []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, string(entry))
+}
+
+func (h *testHook) Name() string {
+	return "testHook"
+}
+
+func (h *testHook) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+func TestNewSetsUpTheLogger(t *testing.T) {
+	out := &syncBuffer{}
+	hook := &testHook{}
+	logger, err := New(
+		WithLevel(enum.LevelDebug),
+		WithEncoder(enum.EncoderText),
+		WithOutput(out),
+		WithBatching(1, 10*time.Millisecond),
+		WithStaticFields(map[string]any{"service": "checkout"}),
+		WithContextParser(func(ctx context.Context) map[string]any {
+			return map[string]any{"trace_id": ctx.Value("trace_id")}
+		}),
+		WithHook(enum.LevelError, hook),
+		WithKeyCollisionPolicy(enum.KeyCollisionSuffix),
+	)
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	cfg := config.GetConfig()
+	assert.Equal(t, enum.LevelDebug, cfg.MinLevel())
+	assert.Equal(t, enum.KeyCollisionSuffix, cfg.KeyCollisionPolicy())
+	assert.Equal(t, enum.EncoderText, cfg.EncoderType())
+	assert.Equal(t, 1, cfg.LogBuffer())
+
+	ctx := context.WithValue(context.Background(), "trace_id", "abc")
+	logger.Debug(ctx, "order placed", model.LogAttr{Key: "order_id", Value: 12345})
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), config.ParseLogField("message", "order placed"))
+	}, time.Second, 5*time.Millisecond)
+	output := out.String()
+	assert.Contains(t, output, config.ParseLogField("order_id", 12345))
+	assert.Contains(t, output, config.ParseLogField("service", "checkout"))
+	assert.Contains(t, output, config.ParseLogField("trace_id", "abc"))
+	assert.False(t, strings.HasPrefix(output, "{"), "the text encoder does not wrap the fields")
+	assert.Equal(t, 0, hook.count(), "the hook only receives error entries")
+
+	logger.Error(ctx, nil, "payment failed")
+	assert.Eventually(t, func() bool { return hook.count() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	config.SetMinLevel(enum.LevelWarn)
+	tests := map[string]Option{
+		"encoder":        WithEncoder("xml"),
+		"output":         WithOutput(nil),
+		"batch size":     WithBatching(0, time.Second),
+		"batch rate":     WithBatching(10, 0),
+		"static fields":  WithStaticFields(map[string]any{"": "x"}),
+		"context parser": WithContextParser(nil),
+		"hook":           WithHook(enum.LevelInfo, nil),
+		"sampler":        WithSampler(nil),
+		"redactor":       WithRedactor(nil),
+		"replace attr":   WithReplaceAttr(nil),
+		"key collision":  WithKeyCollisionPolicy("overwrite"),
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger, err := New(WithLevel(enum.LevelDebug), opt)
+			assert.ErrorIs(t, err, ErrInvalidOption)
+			assert.Nil(t, logger)
+			assert.Equal(t, enum.LevelWarn, config.GetConfig().MinLevel(), "nothing is applied when an option is invalid")
+		})
+	}
+}
+
+func TestNewWithSampler(t *testing.T) {
+	out := &syncBuffer{}
+	logger, err := New(
+		WithLevel(enum.LevelInfo),
+		WithOutput(out),
+		WithBatching(1, 10*time.Millisecond),
+		WithSampler(entry.EveryN(2, enum.LevelError)),
+	)
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	for i := 0; i < 4; i++ {
+		logger.Info(context.Background(), "sampled")
+	}
+	logger.Error(context.Background(), nil, "always")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), config.ParseLogField("message", "always"))
+	}, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return strings.Count(out.String(), config.ParseLogField("message", "sampled")) == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRecoverFlushesTheBatchedEntries(t *testing.T) {
+	out := &syncBuffer{}
+	logger, err := New(
+		WithLevel(enum.LevelInfo),
+		WithOutput(out),
+		WithBatching(100, time.Minute),
+	)
+	assert.NoError(t, err)
+	defer logger.Close()
+
+	logger.Info(context.Background(), "before the panic")
+	func() {
+		defer logger.Recover(context.Background(), nil)
+		panic("boom")
+	}()
+	output := out.String()
+	assert.Contains(t, output, config.ParseLogField("message", "before the panic"))
+	assert.Contains(t, output, config.ParseLogField("message", entry.DefaultRecoverMsg))
+}
+
+func TestSyncLoggerWritesTheSameBytes(t *testing.T) {
+	logAll := func(logger *Logger) {
+		at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		logger.LogAt(at, enum.LevelInfo, context.Background(), "order placed", nil,
+			model.LogAttr{Key: "order_id", Value: 12345}, model.LogAttr{Key: "paid", Value: true})
+		logger.LogAt(at, enum.LevelWarn, context.Background(), "payment retried", errors.New("timeout"))
+	}
+
+	async := &syncBuffer{}
+	logger, err := New(WithLevel(enum.LevelInfo), WithOutput(async), WithBatching(100, time.Minute))
+	assert.NoError(t, err)
+	logAll(logger)
+	logger.Close()
+
+	sync := &syncBuffer{}
+	logger, err = New(WithLevel(enum.LevelInfo), WithOutput(sync), WithBatching(100, time.Minute), WithSync())
+	assert.NoError(t, err)
+	defer logger.Close()
+	logAll(logger)
+
+	assert.Equal(t, 2, strings.Count(sync.String(), "\n"), "the entries are written before the logging calls return")
+	assert.Equal(t, async.String(), sync.String())
+}
+
+func TestCloseIsIdempotentAndRemovesTheHooks(t *testing.T) {
+	hook := &testHook{}
+	logger, err := New(WithLevel(enum.LevelInfo), WithOutput(&syncBuffer{}), WithHook(enum.LevelError, hook), WithSync())
+	assert.NoError(t, err)
+
+	logger.Close()
+	logger.Close()
+	logger.Error(context.Background(), nil, "after close")
+	assert.Equal(t, 0, hook.count(), "the hooks of a closed logger are removed")
+}
+
+func TestCloseWritesTheBatchedEntries(t *testing.T) {
+	out := &syncBuffer{}
+	logger, err := New(WithLevel(enum.LevelInfo), WithOutput(out), WithBatching(100, time.Minute))
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		logger.Info(context.Background(), "before close", model.LogAttr{Key: "n", Value: i})
+	}
+	logger.Close()
+
+	assert.Equal(t, 5, strings.Count(out.String(), "\n"), "every entry is written before Close returns")
+	for i := 0; i < 5; i++ {
+		assert.Contains(t, out.String(), config.ParseLogField("n", i))
+	}
+}
+
+func TestNewReplacesTheHooksOfTheEarlierLogger(t *testing.T) {
+	first := &testHook{}
+	logger, err := New(WithLevel(enum.LevelInfo), WithOutput(&syncBuffer{}), WithHook(enum.LevelError, first), WithSync())
+	assert.NoError(t, err)
+
+	out := &syncBuffer{}
+	next, err := New(WithLevel(enum.LevelInfo), WithOutput(out), WithSync())
+	assert.NoError(t, err)
+	defer next.Close()
+	logger.Close()
+
+	next.Error(context.Background(), nil, "after the second New")
+	assert.Equal(t, 0, first.count(), "the hooks of the earlier logger are removed")
+	assert.Contains(t, out.String(), config.ParseLogField("message", "after the second New"),
+		"closing the earlier logger keeps the setup of the later one")
+}
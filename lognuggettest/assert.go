@@ -0,0 +1,102 @@
+package lognuggettest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+// AssertLogged checks that the observer of tb, or of the closest parent test of tb having one,
+// captured an entry at level whose message contains msgSubstring and that has every attribute
+// of attrs, enum.LevelUnSet matches every level. See Observer.AssertLogged to pass the observer.
+func AssertLogged(tb testing.TB, level enum.LogLevel, msgSubstring string, attrs ...model.LogAttr) bool {
+	tb.Helper()
+	o := observerOf(tb)
+	if o == nil {
+		return false
+	}
+	return o.AssertLogged(tb, level, msgSubstring, attrs...)
+}
+
+// AssertNotLogged checks that the observer of tb captured no entry matching the arguments, see AssertLogged.
+func AssertNotLogged(tb testing.TB, level enum.LogLevel, msgSubstring string, attrs ...model.LogAttr) bool {
+	tb.Helper()
+	o := observerOf(tb)
+	if o == nil {
+		return false
+	}
+	return o.AssertNotLogged(tb, level, msgSubstring, attrs...)
+}
+
+// AssertLogged checks that o captured an entry matching the arguments, see the AssertLogged function.
+func (o *Observer) AssertLogged(tb testing.TB, level enum.LogLevel, msgSubstring string, attrs ...model.LogAttr) bool {
+	tb.Helper()
+	entries := o.Entries()
+	if len(matching(entries, level, msgSubstring, attrs)) == 0 {
+		tb.Errorf("lognuggettest: no entry at level %s with message containing %q and attributes %v, captured:\n%s",
+			level, msgSubstring, attrs, describe(entries))
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged checks that o captured no entry matching the arguments, see the AssertLogged function.
+func (o *Observer) AssertNotLogged(tb testing.TB, level enum.LogLevel, msgSubstring string, attrs ...model.LogAttr) bool {
+	tb.Helper()
+	if found := matching(o.Entries(), level, msgSubstring, attrs); len(found) > 0 {
+		tb.Errorf("lognuggettest: unexpected entry at level %s with message containing %q and attributes %v, found:\n%s",
+			level, msgSubstring, attrs, describe(found))
+		return false
+	}
+	return true
+}
+
+// observerOf returns the observer of tb or of its closest parent test, the subtests
+// are named after their parent, e.g. "TestHandler/not_found".
+func observerOf(tb testing.TB) *Observer {
+	tb.Helper()
+	for name := tb.Name(); ; {
+		if o, ok := observers.Load(name); ok {
+			return o.(*Observer)
+		}
+		i := strings.LastIndexByte(name, '/')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	tb.Errorf("lognuggettest: no observer for %s or its parent tests, create it with lognuggettest.New(t)", tb.Name())
+	return nil
+}
+
+func matching(entries Entries, level enum.LogLevel, msgSubstring string, attrs []model.LogAttr) Entries {
+	return entries.Filter(func(e Entry) bool {
+		if level != enum.LevelUnSet && e.Level != level {
+			return false
+		}
+		if !strings.Contains(e.Message, msgSubstring) {
+			return false
+		}
+		for _, a := range attrs {
+			if !e.HasAttr(string(a.Key), a.Value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func describe(entries Entries) string {
+	if len(entries) == 0 {
+		return "\t(none)"
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString("\t")
+		b.WriteString(e.Raw)
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
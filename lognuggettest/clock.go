@@ -0,0 +1,98 @@
+package lognuggettest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	customTime "github.com/architagr/lognugget/custom_time"
+)
+
+// FakeClock is a customTime.Clock whose time only moves when the test advances it,
+// its tickers tick when Advance reaches their next tick, e.g. to flush the batched entries.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers map[*fakeTicker]struct{}
+}
+
+// NewFakeClock creates a fake clock at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now, tickers: make(map[*fakeTicker]struct{})}
+}
+
+// UseFakeClock makes a fake clock at now the clock of the library until the test finished,
+// the tickers of the post processors created after it are driven by Advance.
+func UseFakeClock(tb testing.TB, now time.Time) *FakeClock {
+	tb.Helper()
+	c := NewFakeClock(now)
+	customTime.SetClock(c)
+	tb.Cleanup(func() { customTime.SetClock(nil) })
+	return c
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker creates a ticker ticking every d of the clock, d must be greater than zero.
+func (c *FakeClock) NewTicker(d time.Duration) customTime.Ticker {
+	if d <= 0 {
+		panic("lognuggettest: non-positive interval for NewTicker")
+	}
+	t := &fakeTicker{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d and ticks the tickers whose next tick is reached.
+// Like time.Ticker, a ticker ticks once when its previous tick was not received yet.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+		select {
+		case t.c <- t.next:
+		default:
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+// Reset restarts the ticker with the period d from the current time of its clock.
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("lognuggettest: non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.period, t.next = d, t.clock.now.Add(d)
+	t.clock.tickers[t] = struct{}{}
+}
+
+// Stop stops the ticks, a tick already sent stays in the channel.
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	delete(t.clock.tickers, t)
+}
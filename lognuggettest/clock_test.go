@@ -0,0 +1,64 @@
+package lognuggettest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseFakeClockGivesStableTimestamps(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	clock := UseFakeClock(t, start)
+	o := New(t)
+	timeField := func(at time.Time) string {
+		return config.ParseLogField("time", customTime.Format(at, config.GetConfig().TimeFormat()))
+	}
+
+	o.Logger().Info(context.Background(), "first")
+	clock.Advance(time.Hour)
+	done := o.Logger().StartOperation(context.Background(), "sync")
+	clock.Advance(2 * time.Second)
+	done(nil)
+
+	entries := o.Entries()
+	if assert.Len(t, entries, 2) {
+		assert.Contains(t, entries[0].Raw, timeField(start))
+		assert.Contains(t, entries[1].Raw, timeField(start.Add(time.Hour+2*time.Second)))
+		assert.True(t, entries[1].HasAttr("duration", 2*time.Second), "durations are measured on the clock")
+	}
+	assert.Equal(t, time.Second, customTime.Since(start.Add(time.Hour+time.Second)))
+}
+
+func TestFakeTickerTicksWhenAdvanced(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	ticker := clock.NewTicker(time.Second)
+	ticks := func() int {
+		n := 0
+		for {
+			select {
+			case <-ticker.C():
+				n++
+			default:
+				return n
+			}
+		}
+	}
+
+	clock.Advance(999 * time.Millisecond)
+	assert.Equal(t, 0, ticks())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, 1, ticks())
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, 1, ticks(), "the ticks missed by a slow receiver are dropped")
+
+	ticker.Reset(time.Minute)
+	clock.Advance(time.Second)
+	assert.Equal(t, 0, ticks())
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	assert.Equal(t, 0, ticks())
+}
@@ -0,0 +1,164 @@
+// Package lognuggettest captures the entries logged by the code under test, decoded,
+// so tests can query and assert them without reading the output of the pipeline.
+//
+// The entries are encoded with the global config, so the field keys set by config.SetDefaultFields
+// and the encoder decide how they are decoded. A test changing the config must not run in parallel
+// with tests using an observer.
+package lognuggettest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+// observers are the observers of the running tests by test name, AssertLogged finds the one of its test here.
+var observers sync.Map // string -> *Observer
+
+// Entry is a captured entry, Attrs holds every field but the time, level and message,
+// with the values as decoded from JSON, e.g. strings and the maps of error fields.
+type Entry struct {
+	Level   enum.LogLevel
+	Message string
+	Attrs   map[string]any
+	Raw     string // the entry as encoded
+}
+
+// Attr returns the value of the field key.
+func (e Entry) Attr(key string) (any, bool) {
+	value, ok := e.Attrs[key]
+	return value, ok
+}
+
+// HasAttr reports whether the entry has the field key with value, compared as encoded.
+func (e Entry) HasAttr(key string, value any) bool {
+	got, ok := e.Attrs[key]
+	return ok && reflect.DeepEqual(got, decodeValue(key, value))
+}
+
+// Entries are captured entries in the order they were logged.
+type Entries []Entry
+
+// Filter returns the entries keep returns true for.
+func (es Entries) Filter(keep func(e Entry) bool) Entries {
+	var filtered Entries
+	for _, e := range es {
+		if keep(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel returns the entries at level.
+func (es Entries) FilterLevel(level enum.LogLevel) Entries {
+	return es.Filter(func(e Entry) bool { return e.Level == level })
+}
+
+// FilterMessage returns the entries whose message contains substr.
+func (es Entries) FilterMessage(substr string) Entries {
+	return es.Filter(func(e Entry) bool { return strings.Contains(e.Message, substr) })
+}
+
+// FilterAttr returns the entries with the field key with value, compared as encoded.
+func (es Entries) FilterAttr(key string, value any) Entries {
+	return es.Filter(func(e Entry) bool { return e.HasAttr(key, value) })
+}
+
+// Messages returns the messages of the entries.
+func (es Entries) Messages() []string {
+	messages := make([]string, 0, len(es))
+	for _, e := range es {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+// Observer is a sink capturing the entries of its logger synchronously, the entries
+// are captured before the logging call returns and are only seen by this observer.
+type Observer struct {
+	mu      sync.Mutex
+	entries Entries
+	logger  *entry.LogEntry
+}
+
+// New creates the observer of the test tb, its logger logs every level to the observer
+// only, so parallel tests do not see each other's entries. The observer is used by
+// AssertLogged and AssertNotLogged in tb and its subtests until tb finished.
+func New(tb testing.TB) *Observer {
+	tb.Helper()
+	o := &Observer{}
+	o.logger = entry.NewLogEntry().WithSink(o).WithMinLevel(enum.LevelTrace)
+	observers.Store(tb.Name(), o)
+	tb.Cleanup(func() { observers.Delete(tb.Name()) })
+	return o
+}
+
+// Logger returns the logger writing to the observer, pass it to the code under test.
+func (o *Observer) Logger() *entry.LogEntry {
+	return o.logger
+}
+
+// WriteEntry decodes and captures the entry, see entry.Sink.
+func (o *Observer) WriteEntry(level enum.LogLevel, data []byte) {
+	e := decode(level, data)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = append(o.entries, e)
+}
+
+// Entries returns the entries captured so far.
+func (o *Observer) Entries() Entries {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append(Entries(nil), o.entries...)
+}
+
+// Len returns the number of entries captured so far.
+func (o *Observer) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// Reset drops the entries captured so far.
+func (o *Observer) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = nil
+}
+
+// decode decodes the JSON or text encoded entry, the time, level and message
+// fields, named by the default fields of the current config, are taken out of the attributes.
+func decode(level enum.LogLevel, data []byte) Entry {
+	e := Entry{Level: level, Raw: string(data)}
+	if len(data) == 0 || data[0] != '{' {
+		data = append(append([]byte{'{'}, data...), '}')
+	}
+	if err := json.Unmarshal(data, &e.Attrs); err != nil {
+		return e
+	}
+	keys := config.GetConfig().DefaultFields()
+	e.Message, _ = e.Attrs[keys[enum.DefaultLogKeyMessage]].(string)
+	delete(e.Attrs, keys[enum.DefaultLogKeyTime])
+	delete(e.Attrs, keys[enum.DefaultLogKeyLevel])
+	delete(e.Attrs, keys[enum.DefaultLogKeyMessage])
+	return e
+}
+
+// decodeValue returns value as it is decoded from an entry it was logged in under key.
+func decodeValue(key string, value any) any {
+	data := config.AppendLogField([]byte{'{'}, key, model.Resolve(value))
+	var field map[string]any
+	if err := json.Unmarshal(append(data, '}'), &field); err != nil {
+		return nil
+	}
+	return field[key]
+}
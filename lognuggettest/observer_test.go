@@ -0,0 +1,120 @@
+package lognuggettest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTB records the errors of the assertions instead of failing the test,
+// a non empty name replaces the name of the test.
+type recordingTB struct {
+	testing.TB
+	name   string
+	errors []string
+}
+
+func (r *recordingTB) Name() string {
+	if r.name != "" {
+		return r.name
+	}
+	return r.TB.Name()
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestObserverCapturesDecodedEntries(t *testing.T) {
+	t.Parallel()
+	o := New(t)
+	logger := o.Logger()
+
+	logger.Debug(context.Background(), "cache miss", model.LogAttr{Key: "key", Value: "user:1"})
+	logger.Error(context.Background(), errors.New("timeout"), "lookup failed", model.LogAttr{Key: "attempt", Value: 3})
+
+	entries := o.Entries()
+	assert.Len(t, entries, 2, "the entries are captured before the logging call returns")
+	assert.Equal(t, enum.LevelDebug, entries[0].Level)
+	assert.Equal(t, "cache miss", entries[0].Message)
+	assert.Equal(t, map[string]any{"key": "user:1"}, entries[0].Attrs)
+
+	failed := entries.FilterLevel(enum.LevelError)
+	assert.Equal(t, []string{"lookup failed"}, failed.Messages())
+	assert.True(t, failed[0].HasAttr("attempt", 3))
+	value, _ := failed[0].Attr("error")
+	assert.Equal(t, "timeout", value.(map[string]any)["message"])
+
+	assert.Len(t, entries.FilterMessage("miss"), 1)
+	assert.Len(t, entries.FilterAttr("attempt", 3), 1)
+	assert.Empty(t, entries.FilterAttr("attempt", 4))
+
+	o.Reset()
+	assert.Equal(t, 0, o.Len())
+}
+
+func TestAssertLogged(t *testing.T) {
+	t.Parallel()
+	o := New(t)
+	o.Logger().Warn(context.Background(), "disk almost full", model.LogAttr{Key: "free_mb", Value: 120})
+
+	AssertLogged(t, enum.LevelWarn, "almost full", model.LogAttr{Key: "free_mb", Value: 120})
+	AssertLogged(t, enum.LevelUnSet, "disk")
+	AssertNotLogged(t, enum.LevelError, "disk")
+}
+
+func TestAssertLoggedReportsMissingEntries(t *testing.T) {
+	t.Parallel()
+	rec := &recordingTB{TB: t}
+	o := New(rec)
+	o.Logger().Info(context.Background(), "started")
+
+	assert.False(t, AssertLogged(rec, enum.LevelInfo, "stopped"))
+	assert.False(t, AssertLogged(rec, enum.LevelInfo, "started", model.LogAttr{Key: "port", Value: 80}))
+	assert.False(t, AssertNotLogged(rec, enum.LevelInfo, "started"))
+	if assert.Len(t, rec.errors, 3) {
+		assert.Contains(t, rec.errors[0], `"message": "started"`, "the captured entries are listed")
+	}
+
+	other := &recordingTB{TB: t, name: t.Name() + "Other"}
+	assert.False(t, AssertLogged(other, enum.LevelInfo, "started"), "the entries of another test are not seen")
+	assert.Len(t, other.errors, 1)
+}
+
+func TestSubtestsUseTheObserverOfTheirParent(t *testing.T) {
+	t.Parallel()
+	o := New(t)
+	o.Logger().Info(context.Background(), "started")
+
+	t.Run("nested", func(t *testing.T) {
+		t.Run("deeper", func(t *testing.T) {
+			AssertLogged(t, enum.LevelInfo, "started")
+		})
+		own := New(t)
+		own.Logger().Info(context.Background(), "nested")
+		AssertLogged(t, enum.LevelInfo, "nested")
+		AssertNotLogged(t, enum.LevelInfo, "started")
+		o.AssertLogged(t, enum.LevelInfo, "started")
+	})
+	AssertNotLogged(t, enum.LevelInfo, "nested")
+}
+
+func TestParallelTestsAreIsolated(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		i := i
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			o := New(t)
+			for n := 0; n < 50; n++ {
+				o.Logger().Info(context.Background(), "tick", model.LogAttr{Key: "test", Value: i})
+			}
+			assert.Len(t, o.Entries().FilterAttr("test", i), 50)
+			assert.Equal(t, 50, o.Len())
+		})
+	}
+}
@@ -1,8 +1,60 @@
 package model
 
+import "fmt"
+
+// maxResolveDepth bounds the LogValuer chain so a value resolving to itself cannot loop forever.
+const maxResolveDepth = 100
+
 type LogAttrKey string
 type LogAttrValue any
 type LogAttr struct {
 	Key   LogAttrKey
 	Value LogAttrValue
 }
+
+// Encoded is a value already encoded as it is written between the quotes of a field, the
+// typed setters of event.Event log their values as *Encoded instead of boxing them.
+// B is only valid until the entry is logged.
+type Encoded struct {
+	B []byte
+}
+
+// LogValuer is implemented by values that compute what is logged, LogValue
+// is only called once the level check passed and the entry is being logged.
+type LogValuer interface {
+	LogValue() any
+}
+
+// LogValuerFunc is a function used as a LogValuer.
+type LogValuerFunc func() any
+
+func (f LogValuerFunc) LogValue() any {
+	return f()
+}
+
+// Lazy returns an attribute whose value is computed by fn only when the entry is logged.
+func Lazy(key string, fn func() any) LogAttr {
+	return LogAttr{Key: LogAttrKey(key), Value: LogValuerFunc(fn)}
+}
+
+// Resolve calls LogValue until the value is no longer a LogValuer.
+// A panic in LogValue is recovered and returned as an error value.
+func Resolve(value LogAttrValue) (resolved LogAttrValue) {
+	for i := 0; i < maxResolveDepth; i++ {
+		valuer, ok := value.(LogValuer)
+		if !ok {
+			return value
+		}
+		value = logValue(valuer)
+	}
+	return fmt.Errorf("LogValue called too many times on type %T", value)
+}
+
+func logValue(valuer LogValuer) (value any) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Errorf("LogValue panicked: %v", r)
+		}
+	}()
+	return valuer.LogValue()
+}
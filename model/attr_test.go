@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type selfValuer struct{}
+
+func (v selfValuer) LogValue() any {
+	return v
+}
+
+func TestResolve(t *testing.T) {
+	assert.Equal(t, "plain", Resolve("plain"))
+	assert.Equal(t, 42, Resolve(LogValuerFunc(func() any { return 42 })))
+	assert.Equal(t, "nested", Resolve(LogValuerFunc(func() any {
+		return LogValuerFunc(func() any { return "nested" })
+	})))
+}
+
+func TestResolveRecoversPanic(t *testing.T) {
+	value := Resolve(LogValuerFunc(func() any { panic("boom") }))
+	assert.EqualError(t, value.(error), "LogValue panicked: boom")
+}
+
+func TestResolveStopsOnLoop(t *testing.T) {
+	value := Resolve(selfValuer{})
+	assert.EqualError(t, value.(error), "LogValue called too many times on type model.selfValuer")
+}
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	attr := Lazy("diff", func() any {
+		calls++
+		return "computed"
+	})
+	assert.Equal(t, LogAttrKey("diff"), attr.Key)
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, "computed", Resolve(attr.Value))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,57 @@
+package model
+
+// maxErrorChain bounds the errors visited in a chain, so a cyclic Unwrap cannot loop forever.
+const maxErrorChain = 32
+
+// StackTracer is implemented by errors carrying the stack where they were created,
+// the program counters are read with runtime.CallersFrames.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// ErrorFielder is implemented by errors adding structured fields to the error
+// field of the entry, e.g. the status code or the id of the failed request.
+type ErrorFielder interface {
+	ErrorFields() []LogAttr
+}
+
+// ErrorChain calls fn for err and every error it wraps, in depth first order as
+// returned by Unwrap() error and Unwrap() []error, until fn returns false.
+func ErrorChain(err error, fn func(err error) bool) {
+	visited := 0
+	walkErrorChain(err, fn, &visited)
+}
+
+func walkErrorChain(err error, fn func(err error) bool, visited *int) bool {
+	if err == nil {
+		return true
+	}
+	if *visited++; *visited > maxErrorChain || !fn(err) {
+		return false
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return walkErrorChain(wrapped.Unwrap(), fn, visited)
+	case interface{ Unwrap() []error }:
+		for _, err := range wrapped.Unwrap() {
+			if !walkErrorChain(err, fn, visited) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ErrorStack returns the stack of the innermost error of the chain of err carrying one, or nil.
+func ErrorStack(err error) []uintptr {
+	var stack []uintptr
+	ErrorChain(err, func(err error) bool {
+		if tracer, ok := err.(StackTracer); ok {
+			if trace := tracer.StackTrace(); len(trace) > 0 {
+				stack = trace
+			}
+		}
+		return true
+	})
+	return stack
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cyclicError struct{}
+
+func (e cyclicError) Error() string { return "cyclic" }
+func (e cyclicError) Unwrap() error { return e }
+
+func TestErrorChain(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	err := fmt.Errorf("wrapped: %w", errors.Join(first, second))
+
+	var chain []error
+	ErrorChain(err, func(err error) bool {
+		chain = append(chain, err)
+		return true
+	})
+	assert.Equal(t, []error{err, errors.Unwrap(err), first, second}, chain)
+
+	chain = nil
+	ErrorChain(err, func(err error) bool {
+		chain = append(chain, err)
+		return err != first
+	})
+	assert.Equal(t, []error{err, errors.Unwrap(err), first}, chain, "the walk stops when fn returns false")
+}
+
+func TestErrorChainStopsOnCycle(t *testing.T) {
+	n := 0
+	ErrorChain(cyclicError{}, func(error) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, maxErrorChain, n)
+}
@@ -14,13 +14,16 @@ func init() {
 	})
 }
 
+// publishLogMessageHookContract is implemented by the hooks, entry is only
+// valid until PublishLogMessage returns and must be copied to be retained.
 type publishLogMessageHookContract interface {
 	PublishLogMessage(entry []byte)
 	Name() string
 }
 
 type eventPreProcessorObserver struct {
-	hooks map[enum.LogLevel]map[string]publishLogMessageHookContract
+	mu    sync.RWMutex
+	hooks map[enum.LogLevel]map[string]publishLogMessageHookContract // replaced, never mutated, once published
 }
 
 func newEventPreProcessingObserver() *eventPreProcessorObserver {
@@ -30,27 +33,65 @@ func newEventPreProcessingObserver() *eventPreProcessorObserver {
 }
 
 func (e *eventPreProcessorObserver) RegisterHook(level enum.LogLevel, hook publishLogMessageHookContract) {
-	levelHooks, exists := e.hooks[level]
-	if !exists {
-		levelHooks = make(map[string]publishLogMessageHookContract)
-	}
-	levelHooks[hook.Name()] = hook
-	e.hooks[level] = levelHooks
+	e.updateHooks(level, func(levelHooks map[string]publishLogMessageHookContract) {
+		levelHooks[hook.Name()] = hook
+	})
 }
 
 func (e *eventPreProcessorObserver) DeRegisterHook(level enum.LogLevel, hookName string) {
-	levelHooks, exists := e.hooks[level]
-	if !exists {
-		return
+	e.updateHooks(level, func(levelHooks map[string]publishLogMessageHookContract) {
+		delete(levelHooks, hookName)
+	})
+}
+
+// updateHooks applies update to a copy of the hooks of level and publishes the copy,
+// so registering a hook never races with PreProcess reading the hooks.
+func (e *eventPreProcessorObserver) updateHooks(level enum.LogLevel, update func(levelHooks map[string]publishLogMessageHookContract)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	hooks := make(map[enum.LogLevel]map[string]publishLogMessageHookContract, len(e.hooks)+1)
+	for l, levelHooks := range e.hooks {
+		hooks[l] = levelHooks
+	}
+	levelHooks := make(map[string]publishLogMessageHookContract, len(e.hooks[level])+1)
+	for name, hook := range e.hooks[level] {
+		levelHooks[name] = hook
 	}
-	delete(levelHooks, hookName)
-	e.hooks[level] = levelHooks
+	update(levelHooks)
+	hooks[level] = levelHooks
+	e.hooks = hooks
 }
 
 func (e *eventPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
-	publish(logMsg, e.hooks[enum.LevelUnSet])
-	publish(logMsg, e.hooks[level])
+	e.mu.RLock()
+	hooks := e.hooks
+	e.mu.RUnlock()
+	publish(logMsg, hooks[enum.LevelUnSet])
+	if level != enum.LevelUnSet {
+		publish(logMsg, hooks[level])
+	}
+}
 
+// PreProcessSync hands logMsg to the hooks as PreProcess does, the hooks batching their entries
+// write it before PreProcessSync returns without flushing their batch, see config.SyncPreProcessor.
+func (e *eventPreProcessorObserver) PreProcessSync(level enum.LogLevel, logMsg []byte) {
+	e.mu.RLock()
+	hooks := e.hooks
+	e.mu.RUnlock()
+	publishSync(logMsg, hooks[enum.LevelUnSet])
+	if level != enum.LevelUnSet {
+		publishSync(logMsg, hooks[level])
+	}
+}
+
+func publishSync(byteData []byte, hooks map[string]publishLogMessageHookContract) {
+	for _, hook := range hooks {
+		if writer, ok := hook.(interface{ WriteLogMessage(entry []byte) }); ok {
+			writer.WriteLogMessage(byteData)
+			continue
+		}
+		hook.PublishLogMessage(byteData)
+	}
 }
 
 func publish(byteData []byte, hooks map[string]publishLogMessageHookContract /*, wg *sync.WaitGroup*/) {
@@ -60,6 +101,20 @@ func publish(byteData []byte, hooks map[string]publishLogMessageHookContract /*,
 	}
 }
 
+// Flush flushes the registered hooks holding entries, see config.Flusher.
+func (e *eventPreProcessorObserver) Flush() {
+	e.mu.RLock()
+	hooks := e.hooks
+	e.mu.RUnlock()
+	for _, levelHooks := range hooks {
+		for _, hook := range levelHooks {
+			if flusher, ok := hook.(interface{ Flush() }); ok {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
 func (e *eventPreProcessorObserver) Name() string {
 	return "EventPreProcessorObserver"
 }
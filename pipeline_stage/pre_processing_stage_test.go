@@ -2,6 +2,7 @@ package pipelineStage
 
 import (
 	"testing"
+	"time"
 
 	"github.com/architagr/lognugget/enum"
 	"github.com/stretchr/testify/assert"
@@ -69,3 +70,19 @@ func TestDeregister(t *testing.T) {
 	assert.True(t, unsetHook.isCalled)
 	assert.False(t, debugHook.isCalled)
 }
+
+func TestPreProcessSyncWritesWithoutFlushingTheBatch(t *testing.T) {
+	out := &linesWriter{}
+	batching := NewUnsetLogEventPostProcessor(time.Minute, 10, out)
+	defer batching.Stop()
+	debugHook := &mockDebugHook{}
+	obj := newEventPreProcessingObserver()
+	obj.RegisterHook(enum.LevelUnSet, batching)
+	obj.RegisterHook(enum.LevelDebug, debugHook)
+
+	obj.PreProcess(enum.LevelInfo, []byte("batched"))
+	obj.PreProcessSync(enum.LevelDebug, []byte("written"))
+
+	assert.Equal(t, []string{"written"}, out.Lines())
+	assert.True(t, debugHook.isCalled, "the hooks without a write are published to")
+}
@@ -0,0 +1,42 @@
+package pipelineStage
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// syncLogEventPostProcessor writes each message to the output as soon as it is
+// published, with the same bytes as the batching post processor.
+type syncLogEventPostProcessor struct {
+	mu     sync.Mutex
+	output io.Writer
+}
+
+// NewSyncLogEventPostProcessor creates a post processor writing without batching,
+// it replaces the batching post processor when registered.
+func NewSyncLogEventPostProcessor(output io.Writer) *syncLogEventPostProcessor {
+	return &syncLogEventPostProcessor{output: output}
+}
+
+// PublishLogMessage writes the message before it returns.
+func (h *syncLogEventPostProcessor) PublishLogMessage(entry []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeMessage(h.output, entry)
+}
+
+// Reconfigure switches to output, rate and maxBufferSize are not used without batching.
+func (h *syncLogEventPostProcessor) Reconfigure(_ time.Duration, _ int, output io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.output = output
+}
+
+// Name returns processor name.
+func (h *syncLogEventPostProcessor) Name() string {
+	return postProcessorName
+}
+
+// Stop does nothing, every message is already written.
+func (h *syncLogEventPostProcessor) Stop() {}
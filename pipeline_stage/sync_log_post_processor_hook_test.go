@@ -0,0 +1,25 @@
+package pipelineStage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncPublishMessageWritesImmediately(t *testing.T) {
+	var out bytes.Buffer
+	obj := NewSyncLogEventPostProcessor(&out)
+	defer obj.Stop()
+
+	assert.Equal(t, "unsetLogEventPostProcessor", obj.Name(), "it replaces the batching post processor")
+	obj.PublishLogMessage([]byte("test message 1"))
+	obj.PublishLogMessage([]byte("test message 2"))
+	assert.Equal(t, "test message 1\ntest message 2\n", out.String())
+
+	var next bytes.Buffer
+	obj.Reconfigure(time.Second, 10, &next)
+	obj.PublishLogMessage([]byte("test message 3"))
+	assert.Equal(t, "test message 3\n", next.String())
+}
@@ -4,46 +4,68 @@ import (
 	"io"
 	"sync"
 	"time"
+
+	"github.com/architagr/lognugget/buffer"
+	customTime "github.com/architagr/lognugget/custom_time"
 )
 
+var newLine = []byte{'\n'}
+
+// postProcessorName is the name of the post processors writing to the output,
+// so registering one replaces the other.
+const postProcessorName = "unsetLogEventPostProcessor"
+
+// writeMessage writes one message to output, as every post processor does.
+func writeMessage(output io.Writer, message []byte) {
+	output.Write(message)
+	output.Write(newLine)
+}
+
 // unsetLogEventPostProcessor batches log messages and flushes them
 // either periodically or when the bucket reaches capacity.
 type unsetLogEventPostProcessor struct {
 	mu            sync.Mutex
-	activeBucket  [][]byte
+	activeBucket  []*buffer.Buffer
 	maxBucketSize int
 	rate          time.Duration
-	ticker        *time.Ticker
+	ticker        customTime.Ticker
 	output        io.Writer
 	stopCh        chan struct{}
+	stopped       chan struct{} // closed once the watcher wrote the last batch
+	// the buckets are written in the order they were taken from the batch, each write
+	// waits until written reaches its ticket, queued and written are guarded by mu
+	queued  uint64
+	written uint64
+	turn    *sync.Cond // signaled when written grows
 }
 
-// NewUnsetLogEventPostProcessor creates a new post processor.
+// NewUnsetLogEventPostProcessor creates a new post processor, the batches are flushed
+// by a ticker of the clock of the library, see customTime.SetClock.
 func NewUnsetLogEventPostProcessor(rate time.Duration, maxBufferSize int, output io.Writer) *unsetLogEventPostProcessor {
 	obj := &unsetLogEventPostProcessor{
-		activeBucket:  make([][]byte, 0, maxBufferSize),
+		activeBucket:  make([]*buffer.Buffer, 0, maxBufferSize),
 		maxBucketSize: maxBufferSize,
 		rate:          rate,
 		output:        output,
-		ticker:        time.NewTicker(rate),
+		ticker:        customTime.GetClock().NewTicker(rate),
 		stopCh:        make(chan struct{}),
+		stopped:       make(chan struct{}),
 	}
+	obj.turn = sync.NewCond(&obj.mu)
 	go obj.activeBucketWatcher()
 	return obj
 }
 
 // activeBucketWatcher periodically flushes messages.
 func (h *unsetLogEventPostProcessor) activeBucketWatcher() {
+	defer close(h.stopped)
 	for {
 		select {
-		case <-h.ticker.C:
+		case <-h.ticker.C():
 			h.flushLogMessages()
 		case <-h.stopCh:
-			h.flushLogMessages()
-			for len(h.activeBucket) > 0 {
-				time.Sleep(h.rate)
-			}
 			h.ticker.Stop()
+			h.Flush()
 			return
 		}
 	}
@@ -51,7 +73,18 @@ func (h *unsetLogEventPostProcessor) activeBucketWatcher() {
 
 // resetBucket clears the active bucket.
 func (h *unsetLogEventPostProcessor) resetBucket() {
-	h.activeBucket = make([][]byte, 0, h.maxBucketSize)
+	h.activeBucket = make([]*buffer.Buffer, 0, h.maxBucketSize)
+}
+
+// takeBucket takes the batched messages and the ticket of their write, mu is held.
+func (h *unsetLogEventPostProcessor) takeBucket() ([]*buffer.Buffer, uint64) {
+	bucket := h.activeBucket
+	if len(bucket) > 0 {
+		h.resetBucket()
+	}
+	ticket := h.queued
+	h.queued++
+	return bucket, ticket
 }
 
 // flushLogMessages safely extracts and processes messages.
@@ -61,24 +94,88 @@ func (h *unsetLogEventPostProcessor) flushLogMessages() {
 	if len(h.activeBucket) == 0 {
 		return
 	}
+	bucket, ticket := h.takeBucket()
+
+	// process asynchronously, the output is captured so a Reconfigure does not redirect a bucket being written
+	go h.writeInTurn(ticket, h.output, bucket)
+}
+
+// writeInTurn writes bucket once the buckets of the earlier tickets are written.
+func (h *unsetLogEventPostProcessor) writeInTurn(ticket uint64, output io.Writer, bucket []*buffer.Buffer) {
+	h.mu.Lock()
+	h.waitTurn(ticket)
+	h.mu.Unlock()
+
+	h.printMessage(output, bucket)
+	h.endTurn()
+}
+
+// waitTurn waits until the writes of the tickets before ticket are done, mu is held.
+func (h *unsetLogEventPostProcessor) waitTurn(ticket uint64) {
+	for h.written != ticket {
+		h.turn.Wait()
+	}
+}
 
-	backupBucket := h.activeBucket
-	h.resetBucket()
+// endTurn lets the write of the next ticket start.
+func (h *unsetLogEventPostProcessor) endTurn() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.written++
+	h.turn.Broadcast()
+}
 
-	// process asynchronously
-	go h.printMessage(backupBucket)
+// Flush writes the batched messages before it returns, after the buckets already being written.
+func (h *unsetLogEventPostProcessor) Flush() {
+	h.mu.Lock()
+	bucket, ticket := h.takeBucket()
+	output := h.output
+	h.mu.Unlock()
+
+	h.writeInTurn(ticket, output, bucket)
 }
 
-// printMessage writes buffered messages to the output.
-func (h *unsetLogEventPostProcessor) printMessage(data [][]byte) {
+// WriteLogMessage writes entry before it returns, after the buckets already being written
+// but without flushing the batch, see config.SyncPreProcessor.
+func (h *unsetLogEventPostProcessor) WriteLogMessage(entry []byte) {
+	h.mu.Lock()
+	ticket, output := h.queued, h.output
+	h.queued++
+	h.waitTurn(ticket)
+	h.mu.Unlock()
+
+	writeMessage(output, entry)
+	h.endTurn()
+}
+
+// printMessage writes buffered messages to output and releases their buffers.
+func (h *unsetLogEventPostProcessor) printMessage(output io.Writer, data []*buffer.Buffer) {
 	for _, d := range data {
-		h.output.Write(d)
-		h.output.Write([]byte{'\n'})
+		writeMessage(output, d.B)
+		d.Free()
+	}
+}
+
+// Reconfigure flushes the messages batched so far to the current output and
+// switches to the new settings, no message is dropped by the switch.
+func (h *unsetLogEventPostProcessor) Reconfigure(rate time.Duration, maxBufferSize int, output io.Writer) {
+	h.flushLogMessages()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.maxBucketSize = maxBufferSize
+	h.output = output
+	if rate != h.rate {
+		h.rate = rate
+		h.ticker.Reset(rate)
 	}
 }
 
-// PublishLogMessage appends a message and flushes if capacity reached.
+// PublishLogMessage copies the message into the bucket and flushes if capacity reached.
 func (h *unsetLogEventPostProcessor) PublishLogMessage(entry []byte) {
+	buf := buffer.Get()
+	buf.B = append(buf.B, entry...)
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -89,15 +186,17 @@ func (h *unsetLogEventPostProcessor) PublishLogMessage(entry []byte) {
 		h.mu.Lock()
 	}
 
-	h.activeBucket = append(h.activeBucket, entry)
+	h.activeBucket = append(h.activeBucket, buf)
 }
 
 // Name returns processor name.
 func (h *unsetLogEventPostProcessor) Name() string {
-	return "unsetLogEventPostProcessor"
+	return postProcessorName
 }
 
-// Stop safely shuts down the processor.
+// Stop shuts down the processor, it returns once the batched messages and the
+// buckets being written reached the output.
 func (h *unsetLogEventPostProcessor) Stop() {
 	close(h.stopCh)
+	<-h.stopped
 }
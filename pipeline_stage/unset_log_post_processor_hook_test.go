@@ -1,10 +1,12 @@
 package pipelineStage
 
 import (
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/architagr/lognugget/lognuggettest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,14 +56,113 @@ func TestPublishMessageWithIOAfterBufferReached(t *testing.T) {
 }
 
 func TestPublishMessageWithIOAfterRate(t *testing.T) {
+	clock := lognuggettest.UseFakeClock(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
 	out := &mockWriter{}
 	obj := NewUnsetLogEventPostProcessor(500*time.Millisecond, 10, out)
 	defer obj.Stop()
-	time.Sleep(time.Second)
-	assert.Equal(t, 0, out.Count())
 	obj.PublishLogMessage([]byte("test message 1"))
 	obj.PublishLogMessage([]byte("test message 2"))
 	obj.PublishLogMessage([]byte("test message 3"))
+	clock.Advance(499 * time.Millisecond)
+	assert.Never(t, func() bool { return out.Count() > 0 }, 50*time.Millisecond, 10*time.Millisecond, "the batch waits for the next tick")
+
+	clock.Advance(time.Millisecond)
+	assert.Eventually(t, func() bool { return out.Count() == 6 }, time.Second, 10*time.Millisecond)
+}
+
+func TestReconfigureFlushesToPreviousOutput(t *testing.T) {
+	before := &mockWriter{}
+	after := &mockWriter{}
+	obj := NewUnsetLogEventPostProcessor(time.Minute, 10, before)
+	defer obj.Stop()
+
+	obj.PublishLogMessage([]byte("test message 1"))
+	obj.Reconfigure(time.Minute, 1, after)
+	assert.Eventually(t, func() bool { return before.Count() == 2 }, 200*time.Millisecond, 10*time.Millisecond)
+
+	obj.PublishLogMessage([]byte("test message 2"))
+	obj.PublishLogMessage([]byte("test message 3"))
+	assert.Eventually(t, func() bool { return after.Count() == 2 }, 200*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, 2, before.Count())
+}
+
+func TestFlushWritesTheBatchedMessages(t *testing.T) {
+	out := &mockWriter{}
+	obj := NewUnsetLogEventPostProcessor(time.Minute, 2, out)
+	defer obj.Stop()
+
+	obj.PublishLogMessage([]byte("test message 1"))
+	obj.PublishLogMessage([]byte("test message 2"))
+	obj.PublishLogMessage([]byte("test message 3"))
+	obj.Flush()
+	assert.Equal(t, 6, out.Count(), "the bucket being written and the batched message are written before Flush returns")
+
+	obj.Flush()
+	assert.Equal(t, 6, out.Count())
+}
+
+// linesWriter records the messages written, one per line.
+type linesWriter struct {
+	mu    sync.Mutex
+	lines []string
+	line  []byte
+}
+
+func (w *linesWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, c := range p {
+		if c == '\n' {
+			w.lines = append(w.lines, string(w.line))
+			w.line = w.line[:0]
+			continue
+		}
+		w.line = append(w.line, c)
+	}
+	return len(p), nil
+}
+
+func (w *linesWriter) Lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.lines...)
+}
+
+func TestBucketsAreWrittenInOrderWhileFlushing(t *testing.T) {
+	out := &linesWriter{}
+	obj := NewUnsetLogEventPostProcessor(time.Millisecond, 3, out)
+
+	var want []string
+	for i := 0; i < 500; i++ {
+		want = append(want, strconv.Itoa(i))
+	}
+	var flushers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		flushers.Add(1)
+		go func() {
+			defer flushers.Done()
+			for n := 0; n < 100; n++ {
+				obj.Flush()
+			}
+		}()
+	}
+	for _, message := range want {
+		obj.PublishLogMessage([]byte(message))
+	}
+	flushers.Wait()
+	obj.Stop()
+
+	assert.Equal(t, want, out.Lines(), "every message is written once, in the order it was published")
+}
+
+func TestWriteLogMessageDoesNotFlushTheBatch(t *testing.T) {
+	out := &linesWriter{}
+	obj := NewUnsetLogEventPostProcessor(time.Minute, 10, out)
+
+	obj.PublishLogMessage([]byte("batched"))
+	obj.WriteLogMessage([]byte("written"))
+	assert.Equal(t, []string{"written"}, out.Lines())
 
-	assert.Eventually(t, func() bool { return out.Count() == 6 }, time.Second, 50*time.Millisecond)
+	obj.Stop()
+	assert.Equal(t, []string{"written", "batched"}, out.Lines(), "Stop writes the batch before it returns")
 }
@@ -0,0 +1,194 @@
+package redaction
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/architagr/lognugget/model"
+)
+
+const DefaultMask = "[REDACTED]"
+
+var ErrInvalidKeyPattern = errors.New("invalid redaction key pattern")
+
+// DefaultKeys are the key patterns redacted by DefaultOptions.
+var DefaultKeys = []string{
+	"password", "passwd", "pwd", "secret", "*_secret", "token", "*_token",
+	"authorization", "cookie", "set-cookie", "api_key", "apikey", "private_key",
+	"card_number", "cvv", "ssn",
+}
+
+// Pattern masks the parts of string values matched by Regexp, Match is an
+// optional extra check of each match, e.g. the Luhn checksum of a card number.
+type Pattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+	Match  func(s string) bool
+}
+
+var (
+	CreditCardPattern = Pattern{
+		Name:   "credit_card",
+		Regexp: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Match:  luhnValid,
+	}
+	EmailPattern = Pattern{
+		Name:   "email",
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+	JWTPattern = Pattern{
+		Name:   "jwt",
+		Regexp: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+)
+
+// Redactor is implemented by types that know which of their parts may be logged,
+// the value returned by Redact is logged instead of the value itself.
+type Redactor interface {
+	Redact() any
+}
+
+// Options are the options of a Stage.
+type Options struct {
+	Keys     []string  // Keys whose value is masked, case insensitive globs as matched by path.Match (e.g. "*_token")
+	Patterns []Pattern // Patterns masked in string and error values of every other key
+	Mask     string    // Replacement of the masked values, defaults to DefaultMask
+}
+
+// DefaultOptions redacts the DefaultKeys and card numbers, emails and JWTs in string values.
+func DefaultOptions() Options {
+	return Options{
+		Keys:     DefaultKeys,
+		Patterns: []Pattern{CreditCardPattern, EmailPattern, JWTPattern},
+	}
+}
+
+// Stage masks sensitive attribute values before the entry is encoded, so every
+// sink sees the redacted entry. Set it with config.SetRedactor.
+type Stage struct {
+	keys     []string
+	patterns []Pattern
+	mask     string
+}
+
+// New creates a Stage, an error is returned when a key pattern is malformed.
+func New(opts Options) (*Stage, error) {
+	s := &Stage{
+		keys:     make([]string, 0, len(opts.Keys)),
+		patterns: opts.Patterns,
+		mask:     opts.Mask,
+	}
+	if s.mask == "" {
+		s.mask = DefaultMask
+	}
+	for _, key := range opts.Keys {
+		key = strings.ToLower(key)
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyPattern, key)
+		}
+		s.keys = append(s.keys, key)
+	}
+	return s, nil
+}
+
+// RedactAttr returns a with its value masked when its key is sensitive, the value
+// replaced by its Redact result when it is a Redactor, and the patterns masked
+// in string values, including the values of nested maps.
+func (s *Stage) RedactAttr(a model.LogAttr) model.LogAttr {
+	a.Value = s.redact(string(a.Key), a.Value)
+	return a
+}
+
+func (s *Stage) redact(key string, value any) any {
+	if s.sensitiveKey(key) {
+		return s.mask
+	}
+	if redactor, ok := value.(Redactor); ok {
+		value = redactor.Redact()
+	}
+	switch v := value.(type) {
+	case string:
+		return s.redactString(v)
+	case error:
+		if msg := v.Error(); s.redactString(msg) != msg {
+			return s.redactString(msg)
+		}
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, nested := range v {
+			redacted[k] = s.redact(k, nested)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for k, nested := range v {
+			if s.sensitiveKey(k) {
+				redacted[k] = s.mask
+			} else {
+				redacted[k] = s.redactString(nested)
+			}
+		}
+		return redacted
+	}
+	return value
+}
+
+// sensitiveKey matches the key, and its last segment for keys of groups such as "request.password".
+func (s *Stage) sensitiveKey(key string) bool {
+	if len(s.keys) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	last := key
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		last = key[i+1:]
+	}
+	for _, pattern := range s.keys {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, last); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Stage) redactString(value string) string {
+	for _, pattern := range s.patterns {
+		if !pattern.Regexp.MatchString(value) {
+			continue
+		}
+		value = pattern.Regexp.ReplaceAllStringFunc(value, func(match string) string {
+			if pattern.Match != nil && !pattern.Match(match) {
+				return match
+			}
+			return s.mask
+		})
+	}
+	return value
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,142 @@
+package slogHandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const groupSeparator = "."
+
+// HandlerOptions are options for the Handler.
+type HandlerOptions struct {
+	Logger    *entry.LogEntry // Logger the records are sent to, defaults to a new entry
+	AddSource bool            // Add the source file and line of the log call to every entry
+}
+
+// Handler is a slog.Handler that sends the records through the LogNugget pipeline,
+// attributes in groups are flattened into keys joined by a dot (e.g. "request.id"), so the
+// ReplaceAttr of the config sees the dotted key and a nil groups.
+type Handler struct {
+	opts   HandlerOptions
+	attrs  []model.LogAttr // attributes added by WithAttrs, keys already qualified by their groups
+	prefix string          // groups opened by WithGroup, joined by the group separator
+}
+
+// NewHandler creates a Handler, a nil opts uses the default options.
+func NewHandler(opts *HandlerOptions) *Handler {
+	h := &Handler{}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Logger == nil {
+		h.opts.Logger = entry.NewLogEntry()
+	}
+	return h
+}
+
+// LevelFromSlog maps a slog level to the closest LogNugget level at or below it.
+func LevelFromSlog(level slog.Level) enum.LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return enum.LevelTrace
+	case level < slog.LevelInfo:
+		return enum.LevelDebug
+	case level < slog.LevelWarn:
+		return enum.LevelInfo
+	case level < slog.LevelError:
+		return enum.LevelWarn
+	default:
+		return enum.LevelError
+	}
+}
+
+// Enabled reports whether the LogNugget config logs records at level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.opts.Logger.Enabled(LevelFromSlog(level))
+}
+
+// Handle converts the record into LogNugget attributes and logs it.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]model.LogAttr, len(h.attrs), len(h.attrs)+r.NumAttrs()+1)
+	copy(fields, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	formatTimes(config.GetConfig(), fields)
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fields = append(fields, model.LogAttr{
+			Key:   model.LogAttrKey(config.GetConfig().DefaultFields()[enum.DefaultLogKeySource]),
+			Value: model.LogAttrValue(fmt.Sprintf("%s:%d", frame.File, frame.Line)),
+		})
+	}
+	h.opts.Logger.LogAt(r.Time, LevelFromSlog(r.Level), ctx, r.Message, nil, fields...)
+	return nil
+}
+
+// WithAttrs returns a Handler that adds attrs, qualified by the open groups, to every record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = make([]model.LogAttr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(h2.attrs, h.attrs)
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, h.prefix, a)
+	}
+	return &h2
+}
+
+// WithGroup returns a Handler that qualifies the keys of all following attributes with name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + groupSeparator
+	return &h2
+}
+
+// appendAttr resolves a and appends it to fields, groups are flattened,
+// empty attributes and empty groups are dropped.
+func appendAttr(fields []model.LogAttr, prefix string, a slog.Attr) []model.LogAttr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + groupSeparator
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+	return append(fields, model.LogAttr{
+		Key:   model.LogAttrKey(prefix + a.Key),
+		Value: model.LogAttrValue(a.Value.Any()),
+	})
+}
+
+// formatTimes writes the time values of fields in the time format and zone of cfg, as the
+// time of the entry, they are formatted when the record is handled so the attributes added
+// by WithAttrs follow the config too.
+func formatTimes(cfg *config.Config, fields []model.LogAttr) {
+	for i, a := range fields {
+		if t, ok := a.Value.(time.Time); ok {
+			fields[i].Value = string(cfg.AppendTime(nil, t))
+		}
+	}
+}
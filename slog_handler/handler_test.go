@@ -0,0 +1,182 @@
+package slogHandler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	customTime "github.com/architagr/lognugget/custom_time"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPreProcessorObserver struct {
+	mu       sync.Mutex
+	logLevel []enum.LogLevel
+	logEntry []string
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logLevel = append(t.logLevel, level)
+	t.logEntry = append(t.logEntry, string(logMsg))
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+func (t *testPreProcessorObserver) waitFor(tb testing.TB, n int) ([]enum.LogLevel, []string) {
+	assert.Eventually(tb, func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return len(t.logEntry) == n
+	}, time.Second, 5*time.Millisecond)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]enum.LogLevel{}, t.logLevel...), append([]string{}, t.logEntry...)
+}
+
+func setup(level enum.LogLevel) *testPreProcessorObserver {
+	config.SetMinLevel(level)
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+// parse decodes an encoded entry into the map layout expected by slogtest,
+// dotted keys become nested groups and the default keys use the slog names.
+func parse(tb testing.TB, logMsg string) map[string]any {
+	var flat map[string]string
+	require.NoError(tb, json.Unmarshal([]byte(logMsg), &flat))
+	defaultFields := config.GetConfig().DefaultFields()
+	rename := map[string]string{
+		defaultFields[enum.DefaultLogKeyTime]:    slog.TimeKey,
+		defaultFields[enum.DefaultLogKeyLevel]:   slog.LevelKey,
+		defaultFields[enum.DefaultLogKeyMessage]: slog.MessageKey,
+		defaultFields[enum.DefaultLogKeySource]:  slog.SourceKey,
+	}
+	m := map[string]any{}
+	for key, value := range flat {
+		if name, ok := rename[key]; ok {
+			key = name
+		}
+		groups := strings.Split(key, groupSeparator)
+		current := m
+		for _, group := range groups[:len(groups)-1] {
+			next, ok := current[group].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				current[group] = next
+			}
+			current = next
+		}
+		current[groups[len(groups)-1]] = value
+	}
+	return m
+}
+
+func TestSlogTest(t *testing.T) {
+	observer := setup(enum.LevelDebug)
+	err := slogtest.TestHandler(NewHandler(&HandlerOptions{AddSource: true}), func() []map[string]any {
+		config.Flush()
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		results := make([]map[string]any, 0, len(observer.logEntry))
+		for _, logMsg := range observer.logEntry {
+			results = append(results, parse(t, logMsg))
+		}
+		return results
+	})
+	assert.NoError(t, err)
+}
+
+func TestHandlerLevels(t *testing.T) {
+	observer := setup(enum.LevelInfo)
+	logger := slog.New(NewHandler(nil))
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error", "err", "failed")
+	logger.Log(context.Background(), slog.LevelError+4, "critical")
+
+	levels, entries := observer.waitFor(t, 4)
+	assert.Equal(t, []enum.LogLevel{enum.LevelInfo, enum.LevelWarn, enum.LevelError, enum.LevelError}, levels)
+	assert.Contains(t, entries[2], config.ParseLogField("err", "failed"))
+	assert.NotContains(t, entries[0], string(enum.DefaultLogKeySource))
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelDebug))
+}
+
+func TestHandlerWritesTimesInTheConfiguredFormat(t *testing.T) {
+	format, zone := config.GetConfig().TimeFormat(), config.GetConfig().TimeZone()
+	t.Cleanup(func() {
+		config.SetTimeFormat(format)
+		config.SetTimeZone(zone)
+	})
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	ist := time.FixedZone("IST", 5*3600+1800)
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "layout", format: time.RFC3339, want: "2024-05-01T15:30:00+05:30"},
+		{name: "epoch", format: customTime.FormatUnix, want: "1714557600"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observer := setup(enum.LevelInfo)
+			config.SetTimeFormat(tt.format)
+			config.SetTimeZone(ist)
+
+			slog.New(NewHandler(nil)).With(slog.Time("started", at)).
+				Info("served", slog.Time("at", at), slog.Group("request", slog.Any("received", at)))
+
+			_, entries := observer.waitFor(t, 1)
+			assert.Contains(t, entries[0], config.ParseLogField("at", tt.want))
+			assert.Contains(t, entries[0], config.ParseLogField("started", tt.want))
+			assert.Contains(t, entries[0], config.ParseLogField("request.received", tt.want))
+		})
+	}
+}
+
+func TestLevelFromSlog(t *testing.T) {
+	assert.Equal(t, enum.LevelTrace, LevelFromSlog(slog.LevelDebug-4))
+	assert.Equal(t, enum.LevelDebug, LevelFromSlog(slog.LevelDebug))
+	assert.Equal(t, enum.LevelDebug, LevelFromSlog(slog.LevelInfo-1))
+	assert.Equal(t, enum.LevelInfo, LevelFromSlog(slog.LevelInfo))
+	assert.Equal(t, enum.LevelInfo, LevelFromSlog(slog.LevelInfo+2))
+	assert.Equal(t, enum.LevelWarn, LevelFromSlog(slog.LevelWarn))
+	assert.Equal(t, enum.LevelError, LevelFromSlog(slog.LevelError))
+}
+
+func TestReplaceAttrSeesTheDottedKeysOfGroups(t *testing.T) {
+	observer := setup(enum.LevelInfo)
+	var mu sync.Mutex
+	replaced := map[string][]string{}
+	config.SetReplaceAttr(func(groups []string, a model.LogAttr) model.LogAttr {
+		mu.Lock()
+		defer mu.Unlock()
+		replaced[string(a.Key)] = groups
+		return a
+	})
+	t.Cleanup(func() { config.SetReplaceAttr(nil) })
+
+	slog.New(NewHandler(nil)).WithGroup("request").Info("served", slog.Group("user", slog.String("id", "42")))
+
+	observer.waitFor(t, 1)
+	mu.Lock()
+	defer mu.Unlock()
+	groups, ok := replaced["request.user.id"]
+	assert.True(t, ok)
+	assert.Nil(t, groups)
+}
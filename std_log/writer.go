@@ -0,0 +1,101 @@
+package stdLog
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/entry"
+	"github.com/architagr/lognugget/enum"
+	"github.com/architagr/lognugget/model"
+)
+
+const (
+	dateLen         = len("2006/01/02 ")
+	timeLen         = len("15:04:05")
+	microsecondsLen = len(".000000")
+)
+
+// Writer is an io.Writer that logs every line written by a standard library
+// log.Logger at a fixed level. The prefix and flags must match the ones of the
+// log.Logger so the header it writes can be parsed out of the message.
+type Writer struct {
+	logger *entry.LogEntry
+	level  enum.LogLevel
+	prefix string
+	flags  int
+}
+
+// NewWriter creates a Writer for a log.Logger using prefix and flags.
+func NewWriter(level enum.LogLevel, prefix string, flags int) *Writer {
+	return &Writer{
+		logger: entry.NewLogEntry(),
+		level:  level,
+		prefix: prefix,
+		flags:  flags,
+	}
+}
+
+// NewStdLogger returns a log.Logger that writes into LogNugget at level.
+func NewStdLogger(level enum.LogLevel) *log.Logger {
+	return log.New(NewWriter(level, "", 0), "", 0)
+}
+
+// RedirectStdLog sends the output of the standard library default logger into
+// LogNugget at level, keeping its current prefix and flags.
+// The returned function restores the previous output.
+func RedirectStdLog(level enum.LogLevel) func() {
+	previous := log.Writer()
+	log.SetOutput(NewWriter(level, log.Prefix(), log.Flags()))
+	return func() {
+		log.SetOutput(previous)
+	}
+}
+
+// Write logs p as a single entry, it never fails so the log.Logger does not drop lines.
+func (w *Writer) Write(p []byte) (int, error) {
+	if !w.logger.Enabled(w.level) {
+		return len(p), nil
+	}
+	message, source := w.parse(strings.TrimRight(string(p), "\r\n"))
+	keys := config.GetConfig().DefaultFields()
+	fields := make([]model.LogAttr, 0, 2)
+	if source != "" {
+		fields = append(fields, model.LogAttr{Key: model.LogAttrKey(keys[enum.DefaultLogKeySource]), Value: model.LogAttrValue(source)})
+	}
+	if component := strings.TrimSpace(w.prefix); component != "" {
+		fields = append(fields, model.LogAttr{Key: model.LogAttrKey(keys[enum.DefaultLogKeyComponent]), Value: model.LogAttrValue(component)})
+	}
+	w.logger.Log(w.level, context.Background(), message, nil, fields...)
+	return len(p), nil
+}
+
+// parse strips the header written by log.Logger, returning the message
+// and the file:line of the call when the file flags are set.
+func (w *Writer) parse(line string) (message, source string) {
+	if w.flags&log.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, w.prefix)
+	}
+	if w.flags&log.Ldate != 0 && len(line) >= dateLen {
+		line = line[dateLen:]
+	}
+	if w.flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		n := timeLen
+		if w.flags&log.Lmicroseconds != 0 {
+			n += microsecondsLen
+		}
+		if len(line) > n {
+			line = line[n+1:]
+		}
+	}
+	if w.flags&(log.Lshortfile|log.Llongfile) != 0 {
+		if i := strings.Index(line, ": "); i >= 0 {
+			source, line = line[:i], line[i+2:]
+		}
+	}
+	if w.flags&log.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, w.prefix)
+	}
+	return line, source
+}
@@ -0,0 +1,109 @@
+package stdLog
+
+import (
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/architagr/lognugget/config"
+	"github.com/architagr/lognugget/enum"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPreProcessorObserver struct {
+	mu       sync.Mutex
+	logLevel []enum.LogLevel
+	logEntry []string
+}
+
+func (t *testPreProcessorObserver) PreProcess(level enum.LogLevel, logMsg []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logLevel = append(t.logLevel, level)
+	t.logEntry = append(t.logEntry, string(logMsg))
+}
+
+func (t *testPreProcessorObserver) Name() string {
+	return "testPreProcessorObserver"
+}
+
+func (t *testPreProcessorObserver) waitFor(tb testing.TB, n int) ([]enum.LogLevel, []string) {
+	assert.Eventually(tb, func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return len(t.logEntry) == n
+	}, time.Second, 5*time.Millisecond)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]enum.LogLevel{}, t.logLevel...), append([]string{}, t.logEntry...)
+}
+
+func setup(level enum.LogLevel) *testPreProcessorObserver {
+	config.SetMinLevel(level)
+	observer := &testPreProcessorObserver{}
+	config.InitPreProcessors(observer)
+	return observer
+}
+
+func TestWriterParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		flags   int
+		line    string
+		message string
+		source  string
+	}{
+		{name: "no flags", line: "hello world", message: "hello world"},
+		{name: "std flags", prefix: "[server] ", flags: log.LstdFlags, line: "[server] 2009/01/23 01:23:23 hello world", message: "hello world"},
+		{name: "microseconds", flags: log.Ltime | log.Lmicroseconds, line: "01:23:23.123123 hello", message: "hello"},
+		{name: "short file", flags: log.LstdFlags | log.Lshortfile, line: "2009/01/23 01:23:23 main.go:23: hello: world", message: "hello: world", source: "main.go:23"},
+		{name: "msg prefix", prefix: "[db] ", flags: log.Ldate | log.Lmsgprefix, line: "2009/01/23 [db] connected", message: "connected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, source := NewWriter(enum.LevelInfo, tt.prefix, tt.flags).parse(tt.line)
+			assert.Equal(t, tt.message, message)
+			assert.Equal(t, tt.source, source)
+		})
+	}
+}
+
+func TestWriterLogsThroughPipeline(t *testing.T) {
+	observer := setup(enum.LevelDebug)
+	logger := log.New(NewWriter(enum.LevelWarn, "[server] ", log.LstdFlags|log.Lshortfile), "[server] ", log.LstdFlags|log.Lshortfile)
+	logger.Println("disk almost full")
+
+	levels, entries := observer.waitFor(t, 1)
+	assert.Equal(t, enum.LevelWarn, levels[0])
+	assert.Contains(t, entries[0], config.ParseLogField("message", "disk almost full"))
+	assert.Contains(t, entries[0], config.ParseLogField(string(enum.DefaultLogKeyComponent), "[server]"))
+	assert.Contains(t, entries[0], "\"source\": \"writer_test.go:")
+}
+
+func TestNewStdLoggerRespectsMinLevel(t *testing.T) {
+	observer := setup(enum.LevelInfo)
+	NewStdLogger(enum.LevelDebug).Print("dropped")
+	NewStdLogger(enum.LevelInfo).Print("kept")
+
+	levels, entries := observer.waitFor(t, 1)
+	assert.Equal(t, enum.LevelInfo, levels[0])
+	assert.Contains(t, entries[0], config.ParseLogField("message", "kept"))
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	observer := setup(enum.LevelDebug)
+	flags := log.Flags()
+	log.SetFlags(log.LstdFlags)
+	defer log.SetFlags(flags)
+
+	restore := RedirectStdLog(enum.LevelInfo)
+	log.Print("from the std logger")
+	restore()
+
+	_, entries := observer.waitFor(t, 1)
+	assert.Contains(t, entries[0], config.ParseLogField("message", "from the std logger"))
+	_, isWriter := log.Writer().(*Writer)
+	assert.False(t, isWriter)
+}